@@ -1,13 +1,27 @@
 package main
 
 import (
+	"database/sql"
 	"sync/atomic"
+	"time"
 
 	"github.com/geophpherie/boot-dev-chirpy-v2/internal/database"
 )
 
 type apiConfig struct {
-	fileserverHits atomic.Int32
-	dbQueries      database.Queries
-	secret         string
+	fileserverHits    atomic.Int32
+	db                *sql.DB
+	dbQueries         database.Queries
+	secret            string
+	exportDir         string
+	exportWake        chan struct{}
+	mediaDir          string
+	mediaWake         chan struct{}
+	fanOutThreshold   int32
+	fanOutWake        chan struct{}
+	registrationMode  string
+	editWindow        time.Duration
+	maxChirpLength    int
+	maxRedChirpLength int
+	trashRetention    time.Duration
 }
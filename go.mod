@@ -3,13 +3,13 @@ module github.com/geophpherie/boot-dev-chirpy-v2
 go 1.23.2
 
 require (
+	github.com/golang-jwt/jwt/v5 v5.2.1
 	github.com/google/uuid v1.6.0
 	github.com/joho/godotenv v1.5.1
 	github.com/lib/pq v1.10.9
+	github.com/rivo/uniseg v0.4.7
+	golang.org/x/crypto v0.30.0
+	golang.org/x/text v0.21.0
 )
 
-require (
-	github.com/golang-jwt/jwt v3.2.2+incompatible // indirect
-	github.com/golang-jwt/jwt/v5 v5.2.1 // indirect
-	golang.org/x/crypto v0.30.0 // indirect
-)
+require github.com/golang-jwt/jwt v3.2.2+incompatible // indirect
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/geophpherie/boot-dev-chirpy-v2/internal/database"
+	"github.com/geophpherie/boot-dev-chirpy-v2/internal/pagination"
+	"github.com/google/uuid"
+)
+
+type BlockEntry struct {
+	Profile
+	BlockedAt time.Time `json:"blocked_at"`
+}
+
+type MuteEntry struct {
+	Profile
+	MutedAt time.Time `json:"muted_at"`
+}
+
+func (cfg *apiConfig) handlerBlock(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	blockedId, err := uuid.Parse(r.PathValue("userId"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Could not use user id")
+		return
+	}
+
+	if userId == blockedId {
+		errorResponse(w, http.StatusBadRequest, "Cannot block yourself")
+		return
+	}
+
+	if _, err := cfg.dbQueries.GetUser(r.Context(), blockedId); err != nil {
+		errorResponse(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		log.Printf("Error starting transaction! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to block user")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	err = qtx.CreateBlock(r.Context(), database.CreateBlockParams{
+		BlockerID: userId,
+		BlockedID: blockedId,
+	})
+	if err != nil {
+		log.Printf("Error creating block! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to block user")
+		return
+	}
+
+	// a block severs the relationship in both directions
+	err = qtx.DeleteFollowsBetween(r.Context(), database.DeleteFollowsBetweenParams{
+		UserID:  userId,
+		OtherID: blockedId,
+	})
+	if err != nil {
+		log.Printf("Error removing follows! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to block user")
+		return
+	}
+
+	err = qtx.DeleteTimelineEntriesBetween(r.Context(), database.DeleteTimelineEntriesBetweenParams{
+		UserID:  userId,
+		OtherID: blockedId,
+	})
+	if err != nil {
+		log.Printf("Error clearing timelines! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to block user")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing block! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to block user")
+		return
+	}
+
+	jsonResponse(w, http.StatusNoContent, struct{}{})
+}
+
+func (cfg *apiConfig) handlerUnblock(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	blockedId, err := uuid.Parse(r.PathValue("userId"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Could not use user id")
+		return
+	}
+
+	args := database.DeleteBlockParams{
+		BlockerID: userId,
+		BlockedID: blockedId,
+	}
+	if err := cfg.dbQueries.DeleteBlock(r.Context(), args); err != nil {
+		log.Printf("Error deleting block! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to unblock user")
+		return
+	}
+
+	jsonResponse(w, http.StatusNoContent, struct{}{})
+}
+
+func (cfg *apiConfig) handlerGetBlocks(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	page, err := pagination.ParseQuery(r.URL.Query())
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	args := database.GetBlockedUsersParams{
+		UserID:     userId,
+		MaxResults: page.Limit + 1,
+	}
+	args.BeforeCreatedAt, args.BeforeID = cursorArgs(page.Cursor)
+
+	rows, err := cfg.dbQueries.GetBlockedUsers(r.Context(), args)
+	if err != nil {
+		log.Printf("Error getting blocks! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not retrieve blocks")
+		return
+	}
+
+	entries := []BlockEntry{}
+	for _, row := range rows {
+		entries = append(entries, BlockEntry{Profile: profileResponse(row.User), BlockedAt: row.BlockedAt})
+	}
+	entries, nextCursor := paginate(entries, page.Limit, func(entry BlockEntry) pagination.Cursor {
+		return pagination.Cursor{Time: entry.BlockedAt, ID: entry.ID}
+	})
+	pageResponse(w, r, entries, nextCursor)
+}
+
+func (cfg *apiConfig) handlerMute(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	mutedId, err := uuid.Parse(r.PathValue("userId"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Could not use user id")
+		return
+	}
+
+	if userId == mutedId {
+		errorResponse(w, http.StatusBadRequest, "Cannot mute yourself")
+		return
+	}
+
+	if _, err := cfg.dbQueries.GetUser(r.Context(), mutedId); err != nil {
+		errorResponse(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	args := database.CreateMuteParams{
+		MuterID: userId,
+		MutedID: mutedId,
+	}
+	if err := cfg.dbQueries.CreateMute(r.Context(), args); err != nil {
+		log.Printf("Error creating mute! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to mute user")
+		return
+	}
+
+	jsonResponse(w, http.StatusNoContent, struct{}{})
+}
+
+func (cfg *apiConfig) handlerUnmute(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	mutedId, err := uuid.Parse(r.PathValue("userId"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Could not use user id")
+		return
+	}
+
+	args := database.DeleteMuteParams{
+		MuterID: userId,
+		MutedID: mutedId,
+	}
+	if err := cfg.dbQueries.DeleteMute(r.Context(), args); err != nil {
+		log.Printf("Error deleting mute! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to unmute user")
+		return
+	}
+
+	jsonResponse(w, http.StatusNoContent, struct{}{})
+}
+
+func (cfg *apiConfig) handlerGetMutes(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	page, err := pagination.ParseQuery(r.URL.Query())
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	args := database.GetMutedUsersParams{
+		UserID:     userId,
+		MaxResults: page.Limit + 1,
+	}
+	args.BeforeCreatedAt, args.BeforeID = cursorArgs(page.Cursor)
+
+	rows, err := cfg.dbQueries.GetMutedUsers(r.Context(), args)
+	if err != nil {
+		log.Printf("Error getting mutes! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not retrieve mutes")
+		return
+	}
+
+	entries := []MuteEntry{}
+	for _, row := range rows {
+		entries = append(entries, MuteEntry{Profile: profileResponse(row.User), MutedAt: row.MutedAt})
+	}
+	entries, nextCursor := paginate(entries, page.Limit, func(entry MuteEntry) pagination.Cursor {
+		return pagination.Cursor{Time: entry.MutedAt, ID: entry.ID}
+	})
+	pageResponse(w, r, entries, nextCursor)
+}
@@ -0,0 +1,404 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/geophpherie/boot-dev-chirpy-v2/internal/database"
+	"github.com/geophpherie/boot-dev-chirpy-v2/internal/pagination"
+	"github.com/google/uuid"
+)
+
+const maxFolderNameLength = 50
+
+type BookmarkedChirp struct {
+	Chirp
+	FolderId     *uuid.UUID `json:"folder_id,omitempty"`
+	BookmarkedAt time.Time  `json:"bookmarked_at"`
+}
+
+type BookmarkFolder struct {
+	Id        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Name      string    `json:"name"`
+}
+
+func bookmarkFolderResponse(folder database.BookmarkFolder) BookmarkFolder {
+	return BookmarkFolder{
+		Id:        folder.ID,
+		CreatedAt: folder.CreatedAt,
+		UpdatedAt: folder.UpdatedAt,
+		Name:      folder.Name,
+	}
+}
+
+func (cfg *apiConfig) handlerBookmark(w http.ResponseWriter, r *http.Request) {
+	requestParams := struct {
+		FolderId *uuid.UUID `json:"folder_id"`
+	}{}
+
+	// the body is optional, it's only needed to file the bookmark
+	if r.ContentLength != 0 {
+		decoder := json.NewDecoder(r.Body)
+		if err := decoder.Decode(&requestParams); err != nil {
+			log.Printf("Error decoding request body: %v", err)
+			errorResponse(w, http.StatusBadRequest, "Error decoding request body")
+			return
+		}
+	}
+
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chirpId, err := uuid.Parse(r.PathValue("chirpId"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Could not use chirp id")
+		return
+	}
+
+	chirp, err := cfg.dbQueries.GetChirpForViewer(r.Context(), database.GetChirpForViewerParams{
+		ID:       chirpId,
+		ViewerID: uuid.NullUUID{UUID: userId, Valid: true},
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errorResponse(w, http.StatusNotFound, "Chirp not found")
+			return
+		}
+		log.Printf("Error getting chirp! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to bookmark chirp")
+		return
+	}
+	// a rechirp has nothing of its own, the original is what gets bookmarked
+	if chirp.RechirpOf.Valid {
+		errorResponse(w, http.StatusBadRequest, "Rechirps cannot be bookmarked, use the original chirp")
+		return
+	}
+
+	if requestParams.FolderId != nil {
+		user, err := cfg.dbQueries.GetUser(r.Context(), userId)
+		if err != nil {
+			errorResponse(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		if !user.IsChirpyRed {
+			errorResponse(w, http.StatusForbidden, "Bookmark folders require Chirpy Red")
+			return
+		}
+
+		_, err = cfg.dbQueries.GetBookmarkFolder(r.Context(), database.GetBookmarkFolderParams{
+			ID:     *requestParams.FolderId,
+			UserID: userId,
+		})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				errorResponse(w, http.StatusBadRequest, "Bookmark folder not found")
+				return
+			}
+			log.Printf("Error getting bookmark folder! %v", err)
+			errorResponse(w, http.StatusInternalServerError, "Unable to bookmark chirp")
+			return
+		}
+	}
+
+	args := database.CreateBookmarkParams{
+		UserID:   userId,
+		ChirpID:  chirpId,
+		FolderID: nullUUID(requestParams.FolderId),
+	}
+	if err := cfg.dbQueries.CreateBookmark(r.Context(), args); err != nil {
+		log.Printf("Error creating bookmark! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to bookmark chirp")
+		return
+	}
+
+	jsonResponse(w, http.StatusNoContent, struct{}{})
+}
+
+func (cfg *apiConfig) handlerUnbookmark(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chirpId, err := uuid.Parse(r.PathValue("chirpId"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Could not use chirp id")
+		return
+	}
+
+	args := database.DeleteBookmarkParams{
+		UserID:  userId,
+		ChirpID: chirpId,
+	}
+	if _, err := cfg.dbQueries.DeleteBookmark(r.Context(), args); err != nil {
+		log.Printf("Error deleting bookmark! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to remove bookmark")
+		return
+	}
+
+	jsonResponse(w, http.StatusNoContent, struct{}{})
+}
+
+func (cfg *apiConfig) handlerGetBookmarks(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	page, err := pagination.ParseQuery(r.URL.Query())
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	args := database.GetBookmarksParams{
+		UserID:     userId,
+		MaxResults: page.Limit + 1,
+	}
+	if args.FolderID, err = parseIdParam(r.URL.Query(), "folder_id"); err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	args.BeforeCreatedAt, args.BeforeID = cursorArgs(page.Cursor)
+
+	rows, err := cfg.dbQueries.GetBookmarks(r.Context(), args)
+	if err != nil {
+		log.Printf("Error getting bookmarks! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not retrieve bookmarks")
+		return
+	}
+
+	entries := []BookmarkedChirp{}
+	for _, row := range rows {
+		entry := BookmarkedChirp{Chirp: chirpResponse(row.Chirp), BookmarkedAt: row.BookmarkedAt}
+		if row.FolderID.Valid {
+			entry.FolderId = &row.FolderID.UUID
+		}
+		entries = append(entries, entry)
+	}
+	entries, nextCursor := paginate(entries, page.Limit, func(entry BookmarkedChirp) pagination.Cursor {
+		return pagination.Cursor{Time: entry.BookmarkedAt, ID: entry.Id}
+	})
+
+	chirps := []*Chirp{}
+	for i := range entries {
+		chirps = append(chirps, &entries[i].Chirp)
+	}
+	cfg.hydrateChirps(r.Context(), uuid.NullUUID{UUID: userId, Valid: true}, chirps...)
+	pageResponse(w, r, entries, nextCursor)
+}
+
+func validateFolderName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", errors.New("Folder name is required")
+	}
+	if utf8.RuneCountInString(name) > maxFolderNameLength {
+		return "", errors.New("Folder name is too long")
+	}
+	return name, nil
+}
+
+func (cfg *apiConfig) handlerNewBookmarkFolder(w http.ResponseWriter, r *http.Request) {
+	requestParams := struct {
+		Name string `json:"name"`
+	}{}
+
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&requestParams); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		errorResponse(w, http.StatusBadRequest, "Error decoding request body")
+		return
+	}
+
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	user, err := cfg.dbQueries.GetUser(r.Context(), userId)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if !user.IsChirpyRed {
+		errorResponse(w, http.StatusForbidden, "Bookmark folders require Chirpy Red")
+		return
+	}
+
+	name, err := validateFolderName(requestParams.Name)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	folder, err := cfg.dbQueries.CreateBookmarkFolder(r.Context(), database.CreateBookmarkFolderParams{
+		UserID: userId,
+		Name:   name,
+	})
+	if err != nil {
+		if isUniqueViolation(err) {
+			errorResponse(w, http.StatusConflict, "A folder with that name already exists")
+			return
+		}
+		log.Printf("Error creating bookmark folder! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not create folder")
+		return
+	}
+
+	jsonResponse(w, http.StatusCreated, bookmarkFolderResponse(folder))
+}
+
+func (cfg *apiConfig) handlerGetBookmarkFolders(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	folders, err := cfg.dbQueries.GetBookmarkFolders(r.Context(), userId)
+	if err != nil {
+		log.Printf("Error getting bookmark folders! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not retrieve folders")
+		return
+	}
+
+	response := []BookmarkFolder{}
+	for _, folder := range folders {
+		response = append(response, bookmarkFolderResponse(folder))
+	}
+	jsonResponse(w, http.StatusOK, response)
+}
+
+func (cfg *apiConfig) handlerRenameBookmarkFolder(w http.ResponseWriter, r *http.Request) {
+	requestParams := struct {
+		Name string `json:"name"`
+	}{}
+
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&requestParams); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		errorResponse(w, http.StatusBadRequest, "Error decoding request body")
+		return
+	}
+
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	folderId, err := uuid.Parse(r.PathValue("folderId"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Could not use folder id")
+		return
+	}
+
+	name, err := validateFolderName(requestParams.Name)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	folder, err := cfg.dbQueries.RenameBookmarkFolder(r.Context(), database.RenameBookmarkFolderParams{
+		ID:     folderId,
+		UserID: userId,
+		Name:   name,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errorResponse(w, http.StatusNotFound, "Folder not found")
+			return
+		}
+		if isUniqueViolation(err) {
+			errorResponse(w, http.StatusConflict, "A folder with that name already exists")
+			return
+		}
+		log.Printf("Error renaming bookmark folder! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not rename folder")
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, bookmarkFolderResponse(folder))
+}
+
+// handlerDeleteBookmarkFolder removes a folder but keeps its bookmarks,
+// which go back to being unfiled.
+func (cfg *apiConfig) handlerDeleteBookmarkFolder(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	folderId, err := uuid.Parse(r.PathValue("folderId"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Could not use folder id")
+		return
+	}
+
+	deleted, err := cfg.dbQueries.DeleteBookmarkFolder(r.Context(), database.DeleteBookmarkFolderParams{
+		ID:     folderId,
+		UserID: userId,
+	})
+	if err != nil {
+		log.Printf("Error deleting bookmark folder! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not delete folder")
+		return
+	}
+	if deleted == 0 {
+		errorResponse(w, http.StatusNotFound, "Folder not found")
+		return
+	}
+
+	jsonResponse(w, http.StatusNoContent, struct{}{})
+}
+
+func (cfg *apiConfig) markBookmarkedChirps(ctx context.Context, viewerId uuid.NullUUID, chirps []*Chirp) {
+	if !viewerId.Valid {
+		return
+	}
+
+	ids := []uuid.UUID{}
+	for _, chirp := range chirps {
+		if !chirp.Deleted && !chirp.Unavailable {
+			ids = append(ids, chirp.Id)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	bookmarked, err := cfg.dbQueries.GetBookmarkedChirpIds(ctx, database.GetBookmarkedChirpIdsParams{
+		UserID:   viewerId.UUID,
+		ChirpIds: ids,
+	})
+	if err != nil {
+		log.Printf("Error getting bookmarked chirps! %v", err)
+		return
+	}
+
+	for _, chirp := range chirps {
+		if chirp.Deleted || chirp.Unavailable {
+			continue
+		}
+		bookmarkedByMe := slices.Contains(bookmarked, chirp.Id)
+		chirp.BookmarkedByMe = &bookmarkedByMe
+	}
+}
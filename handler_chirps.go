@@ -1,22 +1,156 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"net/url"
 	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/geophpherie/boot-dev-chirpy-v2/internal/auth"
+	"github.com/geophpherie/boot-dev-chirpy-v2/internal/chirptext"
 	"github.com/geophpherie/boot-dev-chirpy-v2/internal/database"
+	"github.com/geophpherie/boot-dev-chirpy-v2/internal/pagination"
 	"github.com/google/uuid"
 )
 
+const (
+	maxFilterAuthors         = 50
+	defaultEditWindow        = 15 * time.Minute
+	defaultMaxChirpLength    = 140
+	defaultMaxRedChirpLength = 280
+)
+
+var errChirpTooLong = errors.New("Chirp is too long")
+var errReplyUnavailable = errors.New("Chirp being replied to not found")
+var errQuoteUnavailable = errors.New("Chirp being quoted not found")
+
+type Chirp struct {
+	Id              uuid.UUID       `json:"id"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+	Body            string          `json:"body"`
+	UserId          uuid.UUID       `json:"user_id"`
+	Edited          bool            `json:"edited"`
+	EditedAt        *time.Time      `json:"edited_at,omitempty"`
+	InReplyTo       *uuid.UUID      `json:"in_reply_to,omitempty"`
+	ConversationId  uuid.UUID       `json:"conversation_id"`
+	Visibility      string          `json:"visibility,omitempty"`
+	ContentWarning  string          `json:"content_warning,omitempty"`
+	Sensitive       bool            `json:"sensitive,omitempty"`
+	SensitiveForced bool            `json:"sensitive_forced,omitempty"`
+	Display         string          `json:"display,omitempty"`
+	ReplyCount      int32           `json:"reply_count"`
+	LikeCount       int32           `json:"like_count"`
+	LikedByMe       *bool           `json:"liked_by_me,omitempty"`
+	BookmarkedByMe  *bool           `json:"bookmarked_by_me,omitempty"`
+	RechirpOfId     *uuid.UUID      `json:"rechirp_of_id,omitempty"`
+	RechirpOf       *Chirp          `json:"rechirp_of,omitempty"`
+	QuoteOfId       *uuid.UUID      `json:"quote_of_id,omitempty"`
+	QuoteOf         *Chirp          `json:"quote_of,omitempty"`
+	Mentions        []MentionEntity `json:"mentions,omitempty"`
+	Media           []Media         `json:"media,omitempty"`
+	Poll            *Poll           `json:"poll,omitempty"`
+	Pinned          bool            `json:"pinned,omitempty"`
+	Deleted         bool            `json:"deleted,omitempty"`
+	Unavailable     bool            `json:"unavailable,omitempty"`
+}
+
+type Thread struct {
+	Ancestors []Chirp       `json:"ancestors"`
+	Chirp     Chirp         `json:"chirp"`
+	Replies   []ThreadReply `json:"replies"`
+}
+
+// ThreadReply is a descendant of the thread's chirp. Depth counts from that
+// chirp, and with in_reply_to it lets clients rebuild the tree from a page.
+type ThreadReply struct {
+	Chirp
+	Depth int32 `json:"depth"`
+}
+
+type ChirpRevision struct {
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func chirpResponse(chirp database.Chirp) Chirp {
+	response := Chirp{
+		Id:              chirp.ID,
+		CreatedAt:       chirp.CreatedAt,
+		UpdatedAt:       chirp.UpdatedAt,
+		Body:            chirp.Body,
+		UserId:          chirp.UserID,
+		Edited:          chirp.EditedAt.Valid,
+		EditedAt:        nullTime(chirp.EditedAt),
+		ConversationId:  chirp.ConversationID,
+		Visibility:      chirp.Visibility,
+		ContentWarning:  chirp.ContentWarning.String,
+		Sensitive:       chirp.Sensitive || chirp.SensitiveForced,
+		SensitiveForced: chirp.SensitiveForced,
+		ReplyCount:      chirp.ReplyCount,
+		LikeCount:       chirp.LikeCount,
+	}
+	if chirp.InReplyTo.Valid {
+		response.InReplyTo = &chirp.InReplyTo.UUID
+	}
+	if chirp.RechirpOf.Valid {
+		response.RechirpOfId = &chirp.RechirpOf.UUID
+	}
+	if chirp.QuoteOf.Valid {
+		response.QuoteOfId = &chirp.QuoteOf.UUID
+	}
+	return response
+}
+
+// tombstoneResponse keeps a deleted or hidden chirp's place in a thread
+// without showing what it said or who said it.
+func tombstoneResponse(chirp database.Chirp) Chirp {
+	response := Chirp{
+		Id:             chirp.ID,
+		CreatedAt:      chirp.CreatedAt,
+		UpdatedAt:      chirp.CreatedAt,
+		ConversationId: chirp.ConversationID,
+		ReplyCount:     chirp.ReplyCount,
+		Deleted:        true,
+	}
+	if chirp.InReplyTo.Valid {
+		response.InReplyTo = &chirp.InReplyTo.UUID
+	}
+	return response
+}
+
+func threadChirpResponse(chirp database.Chirp, hidden bool) Chirp {
+	if hidden || chirp.DeletedAt.Valid {
+		return tombstoneResponse(chirp)
+	}
+	return chirpResponse(chirp)
+}
+
+// chirpInput is what a client supplies to write a chirp, whether directly
+// or by publishing a draft.
+type chirpInput struct {
+	Body           string      `json:"body"`
+	InReplyTo      *uuid.UUID  `json:"in_reply_to"`
+	QuoteOf        *uuid.UUID  `json:"quote_of"`
+	MediaIds       []uuid.UUID `json:"media_ids"`
+	Visibility     string      `json:"visibility"`
+	ContentWarning string      `json:"content_warning"`
+	Sensitive      bool        `json:"sensitive"`
+}
+
 func (cfg *apiConfig) handlerNewChirp(w http.ResponseWriter, r *http.Request) {
 	requestParams := struct {
-		Body string `json:"body"`
+		chirpInput
+		PublishAt *time.Time `json:"publish_at"`
+		Poll      *pollInput `json:"poll"`
 	}{}
 
 	decoder := json.NewDecoder(r.Body)
@@ -38,98 +172,393 @@ func (cfg *apiConfig) handlerNewChirp(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if len(requestParams.Body) > 140 {
-		log.Printf("Chirp too long!")
-		errorResponse(w, http.StatusBadRequest, "Chirp is too long")
+	user, err := cfg.dbQueries.GetUser(r.Context(), userId)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
 
-	cleanChirp := badWordReplacement(requestParams.Body)
+	args, status, err := cfg.prepareChirp(r.Context(), user, requestParams.chirpInput)
+	if err != nil {
+		errorResponse(w, status, err.Error())
+		return
+	}
 
-	args := database.CreateChirpParams{
-		Body:   cleanChirp,
-		UserID: userId,
+	if requestParams.Poll != nil {
+		if requestParams.PublishAt != nil {
+			errorResponse(w, http.StatusBadRequest, "Chirps with polls cannot be scheduled")
+			return
+		}
+		if len(requestParams.MediaIds) > 0 {
+			errorResponse(w, http.StatusBadRequest, "A chirp cannot have both media and a poll")
+			return
+		}
+		if err := validatePoll(requestParams.Poll); err != nil {
+			errorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	if requestParams.PublishAt != nil {
+		cfg.scheduleChirp(w, r, args, requestParams.MediaIds, *requestParams.PublishAt)
+		return
 	}
 
-	chirp, err := cfg.dbQueries.CreateChirp(r.Context(), args)
+	chirp, err := cfg.createChirp(r.Context(), args, requestParams.MediaIds, requestParams.Poll)
 	if err != nil {
+		if errors.Is(err, errMediaUnavailable) || errors.Is(err, errMediaMixed) {
+			errorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
 		log.Printf("Error creating chirp! %v", err)
 		errorResponse(w, http.StatusBadRequest, "Could not create chirp")
 		return
 	}
 
-	response := struct {
-		Id        uuid.UUID `json:"id"`
-		CreatedAt time.Time `json:"created_at"`
-		UpdatedAt time.Time `json:"updated_at"`
-		Body      string    `json:"body"`
-		UserId    uuid.UUID `json:"user_id"`
-	}{
-		Id:        chirp.ID,
+	response := chirpResponse(chirp)
+	cfg.hydrateChirps(r.Context(), uuid.NullUUID{UUID: userId, Valid: true}, &response)
+	jsonResponse(w, http.StatusCreated, response)
+
+}
+
+// prepareChirp runs the checks every new chirp goes through and resolves
+// its reply and quote targets. On failure it returns the status code the
+// error should be reported with.
+func (cfg *apiConfig) prepareChirp(ctx context.Context, user database.User, input chirpInput) (database.CreateChirpParams, int, error) {
+	cleanChirp, err := validateChirpBody(input.Body, cfg.chirpRules(user))
+	if err != nil {
+		return database.CreateChirpParams{}, http.StatusBadRequest, err
+	}
+
+	if err := validateMediaIds(input.MediaIds); err != nil {
+		return database.CreateChirpParams{}, http.StatusBadRequest, err
+	}
+
+	visibility, err := parseVisibility(input.Visibility)
+	if err != nil {
+		return database.CreateChirpParams{}, http.StatusBadRequest, err
+	}
+
+	contentWarning, err := parseContentWarning(input.ContentWarning)
+	if err != nil {
+		return database.CreateChirpParams{}, http.StatusBadRequest, err
+	}
+
+	args := database.CreateChirpParams{
+		Body:           cleanChirp,
+		UserID:         user.ID,
+		Visibility:     visibility,
+		ContentWarning: contentWarning,
+		Sensitive:      input.Sensitive,
+	}
+
+	if input.InReplyTo != nil {
+		parent, err := cfg.getOriginalChirpForViewer(ctx, *input.InReplyTo, uuid.NullUUID{UUID: user.ID, Valid: true})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return database.CreateChirpParams{}, http.StatusNotFound, errReplyUnavailable
+			}
+			log.Printf("Error getting parent chirp! %v", err)
+			return database.CreateChirpParams{}, http.StatusInternalServerError, errors.New("Could not create chirp")
+		}
+		args.InReplyTo = uuid.NullUUID{UUID: parent.ID, Valid: true}
+		args.ConversationID = uuid.NullUUID{UUID: parent.ConversationID, Valid: true}
+	}
+
+	if input.QuoteOf != nil {
+		quoted, err := cfg.getOriginalChirpForViewer(ctx, *input.QuoteOf, uuid.NullUUID{UUID: user.ID, Valid: true})
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return database.CreateChirpParams{}, http.StatusNotFound, errQuoteUnavailable
+			}
+			log.Printf("Error getting quoted chirp! %v", err)
+			return database.CreateChirpParams{}, http.StatusInternalServerError, errors.New("Could not create chirp")
+		}
+		args.QuoteOf = uuid.NullUUID{UUID: quoted.ID, Valid: true}
+	}
+
+	return args, 0, nil
+}
+
+// createChirp stores a chirp along with its attachments, poll and the
+// entities parsed out of its body and publishes it.
+func (cfg *apiConfig) createChirp(ctx context.Context, args database.CreateChirpParams, mediaIds []uuid.UUID, poll *pollInput) (database.Chirp, error) {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	chirp, err := insertChirp(ctx, qtx, args, mediaIds)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+
+	if poll != nil {
+		if err := createPoll(ctx, qtx, chirp.ID, poll); err != nil {
+			return database.Chirp{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return database.Chirp{}, err
+	}
+
+	cfg.publishChirp(ctx, chirp)
+	return chirp, nil
+}
+
+func insertChirp(ctx context.Context, qtx *database.Queries, args database.CreateChirpParams, mediaIds []uuid.UUID) (database.Chirp, error) {
+	chirp, err := qtx.CreateChirp(ctx, args)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+
+	if len(mediaIds) > 0 {
+		if err := linkMedia(ctx, qtx, chirp, mediaIds); err != nil {
+			return database.Chirp{}, err
+		}
+	}
+
+	if err := saveEntities(ctx, qtx, chirp); err != nil {
+		return database.Chirp{}, err
+	}
+	return chirp, nil
+}
+
+func saveEntities(ctx context.Context, qtx *database.Queries, chirp database.Chirp) error {
+	if err := saveHashtags(ctx, qtx, chirp); err != nil {
+		return err
+	}
+	return saveMentions(ctx, qtx, chirp)
+}
+
+// publishChirp puts a freshly created chirp on its author's timeline and
+// wakes the fan-out worker to deliver it to followers.
+func (cfg *apiConfig) publishChirp(ctx context.Context, chirp database.Chirp) {
+	err := cfg.dbQueries.AddTimelineEntry(ctx, database.AddTimelineEntryParams{
+		UserID:    chirp.UserID,
+		ChirpID:   chirp.ID,
+		AuthorID:  chirp.UserID,
 		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
-		Body:      chirp.Body,
-		UserId:    chirp.UserID,
+	})
+	if err != nil {
+		log.Printf("Error adding chirp to own timeline! %v", err)
 	}
-	jsonResponse(w, http.StatusCreated, response)
+	notify(cfg.fanOutWake)
+	cfg.dbQueries.TouchUser(ctx, chirp.UserID)
+}
 
+// getOriginalChirpForViewer follows a rechirp back to the chirp it reposts
+// so rechirps, quotes and replies never point at another rechirp. The
+// original is checked against the viewer as well, since its author may have
+// blocked them or restricted who can see it.
+func (cfg *apiConfig) getOriginalChirpForViewer(ctx context.Context, chirpId uuid.UUID, viewerId uuid.NullUUID) (database.Chirp, error) {
+	chirp, err := cfg.dbQueries.GetChirpForViewer(ctx, database.GetChirpForViewerParams{
+		ID:       chirpId,
+		ViewerID: viewerId,
+	})
+	if err != nil || !chirp.RechirpOf.Valid {
+		return chirp, err
+	}
+	return cfg.dbQueries.GetChirpForViewer(ctx, database.GetChirpForViewerParams{
+		ID:       chirp.RechirpOf.UUID,
+		ViewerID: viewerId,
+	})
 }
 
 func (cfg *apiConfig) handlerGetAllChirps(w http.ResponseWriter, r *http.Request) {
-	q_user_id := r.URL.Query().Get("author_id")
-	q_sort := r.URL.Query().Get("sort")
+	viewerId, err := cfg.viewer(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
 
-	var chirps []database.Chirp
-	var err error
-	if q_user_id == "" {
-		chirps, err = cfg.dbQueries.GetAllChirps(r.Context())
+	page, err := pagination.ParseQuery(r.URL.Query())
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter, err := parseChirpFilter(r.URL.Query())
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter.ViewerID = viewerId
+	filter.CursorTime, filter.CursorID = cursorArgs(page.Cursor)
+	filter.MaxResults = page.Limit + 1
+
+	msg, err := cfg.checkFilterAnchors(r.Context(), filter)
+	if err != nil {
+		log.Printf("Error getting anchor chirp! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not retrieve all chirps.")
+		return
+	}
+	if msg != "" {
+		errorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	includePinned := false
+	if value := r.URL.Query().Get("include_pinned"); value != "" {
+		includePinned, err = strconv.ParseBool(value)
 		if err != nil {
-			log.Printf("Error getting all chirps! %v", err)
-			errorResponse(w, http.StatusInternalServerError, "Could not retrieve all chirps.")
+			errorResponse(w, http.StatusBadRequest, "include_pinned must be true or false")
 			return
 		}
-	} else {
-		userId, err := uuid.Parse(q_user_id)
-		if err != nil {
-			errorResponse(w, http.StatusInternalServerError, "Unknown user")
+		if includePinned && len(filter.AuthorIDs) != 1 {
+			errorResponse(w, http.StatusBadRequest, "include_pinned needs exactly one author_id")
 			return
-
 		}
-		chirps, err = cfg.dbQueries.GetAllChirpsByUserId(r.Context(), userId)
+	}
+
+	chirps, err := cfg.dbQueries.FilterChirps(r.Context(), filter)
+	if err != nil {
+		log.Printf("Error getting all chirps! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not retrieve all chirps.")
+		return
+	}
+
+	// pinned chirps lead the first page only, ahead of the usual ordering
+	pinned := []Chirp{}
+	if includePinned && page.Cursor == nil {
+		pinned, err = cfg.pinnedChirps(r.Context(), filter.AuthorIDs[0], viewerId)
 		if err != nil {
-			log.Printf("Error getting all chirps! %v", err)
+			log.Printf("Error getting pinned chirps! %v", err)
 			errorResponse(w, http.StatusInternalServerError, "Could not retrieve all chirps.")
 			return
 		}
 	}
 
-	type chirpResponse struct {
-		Id        uuid.UUID `json:"id"`
-		CreatedAt time.Time `json:"created_at"`
-		UpdatedAt time.Time `json:"updated_at"`
-		Body      string    `json:"body"`
-		UserId    uuid.UUID `json:"user_id"`
+	cfg.chirpPageResponse(w, r, viewerId, chirps, page.Limit, pinned...)
+}
+
+// parseChirpFilter turns the chirp listing query parameters into a filter,
+// rejecting anything malformed rather than silently ignoring it.
+func parseChirpFilter(query url.Values) (database.ChirpFilter, error) {
+	filter := database.ChirpFilter{}
+
+	switch query.Get("sort") {
+	case "", "asc":
+	case "desc":
+		filter.Descending = true
+	default:
+		return filter, errors.New("sort must be asc or desc")
 	}
 
-	response := []chirpResponse{}
+	if authors := query.Get("author_id"); authors != "" {
+		for _, author := range strings.Split(authors, ",") {
+			authorId, err := uuid.Parse(strings.TrimSpace(author))
+			if err != nil {
+				return filter, fmt.Errorf("invalid author_id %q", author)
+			}
+			filter.AuthorIDs = append(filter.AuthorIDs, authorId)
+		}
+		if len(filter.AuthorIDs) > maxFilterAuthors {
+			return filter, fmt.Errorf("at most %v author_id values are allowed", maxFilterAuthors)
+		}
+	}
 
-	// chirps come back sorted ASC, so only change to DESC if it's given
-	if q_sort == "desc" {
-		slices.Reverse(chirps)
+	var err error
+	if filter.Since, err = parseTimeParam(query, "since"); err != nil {
+		return filter, err
+	}
+	if filter.Until, err = parseTimeParam(query, "until"); err != nil {
+		return filter, err
+	}
+	if filter.Since.Valid && filter.Until.Valid && !filter.Since.Time.Before(filter.Until.Time) {
+		return filter, errors.New("since must be before until")
 	}
 
-	for _, chirp := range chirps {
-		r := chirpResponse{
-			Id:        chirp.ID,
-			CreatedAt: chirp.CreatedAt,
-			UpdatedAt: chirp.UpdatedAt,
-			Body:      chirp.Body,
-			UserId:    chirp.UserID,
+	if filter.SinceID, err = parseIdParam(query, "since_id"); err != nil {
+		return filter, err
+	}
+	if filter.MaxID, err = parseIdParam(query, "max_id"); err != nil {
+		return filter, err
+	}
+
+	switch query.Get("has") {
+	case "":
+	case "links":
+		filter.HasLinks = true
+	case "media":
+		filter.HasMedia = true
+	default:
+		return filter, errors.New("has must be media or links")
+	}
+
+	if value := query.Get("exclude_replies"); value != "" {
+		excludeReplies, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, errors.New("exclude_replies must be true or false")
 		}
-		response = append(response, r)
+		filter.ExcludeReplies = excludeReplies
+	}
 
+	if value := query.Get("sensitive"); value != "" {
+		sensitive, err := strconv.ParseBool(value)
+		if err != nil {
+			return filter, errors.New("sensitive must be true or false")
+		}
+		filter.Sensitive = sql.NullBool{Bool: sensitive, Valid: true}
 	}
-	jsonResponse(w, http.StatusOK, response)
+
+	return filter, nil
+}
+
+func parseTimeParam(query url.Values, name string) (sql.NullTime, error) {
+	value := query.Get(name)
+	if value == "" {
+		return sql.NullTime{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return sql.NullTime{}, fmt.Errorf("%v must be an RFC 3339 timestamp", name)
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}, nil
+}
+
+// checkFilterAnchors makes sure since_id and max_id name chirps the viewer
+// can see. Otherwise the listing would come back empty and look like there
+// was simply nothing new.
+func (cfg *apiConfig) checkFilterAnchors(ctx context.Context, filter database.ChirpFilter) (string, error) {
+	anchors := []struct {
+		name string
+		id   uuid.NullUUID
+	}{{"since_id", filter.SinceID}, {"max_id", filter.MaxID}}
+
+	for _, anchor := range anchors {
+		if !anchor.id.Valid {
+			continue
+		}
+		_, err := cfg.dbQueries.GetChirpForViewer(ctx, database.GetChirpForViewerParams{
+			ID:       anchor.id.UUID,
+			ViewerID: filter.ViewerID,
+		})
+		if err == sql.ErrNoRows {
+			return fmt.Sprintf("%v does not match a chirp", anchor.name), nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return "", nil
+}
+
+func parseIdParam(query url.Values, name string) (uuid.NullUUID, error) {
+	value := query.Get(name)
+	if value == "" {
+		return uuid.NullUUID{}, nil
+	}
+
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return uuid.NullUUID{}, fmt.Errorf("invalid %v", name)
+	}
+	return uuid.NullUUID{UUID: id, Valid: true}, nil
 }
 
 func (cfg *apiConfig) handlerGetChirp(w http.ResponseWriter, r *http.Request) {
@@ -140,7 +569,18 @@ func (cfg *apiConfig) handlerGetChirp(w http.ResponseWriter, r *http.Request) {
 		return
 
 	}
-	chirp, err := cfg.dbQueries.GetChirp(r.Context(), id)
+
+	viewerId, err := cfg.viewer(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	args := database.GetChirpForViewerParams{
+		ID:       id,
+		ViewerID: viewerId,
+	}
+	chirp, err := cfg.dbQueries.GetChirpForViewer(r.Context(), args)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			log.Printf("no chirp found for id %v", id)
@@ -152,22 +592,173 @@ func (cfg *apiConfig) handlerGetChirp(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	type chirpResponse struct {
-		Id        uuid.UUID `json:"id"`
-		CreatedAt time.Time `json:"created_at"`
-		UpdatedAt time.Time `json:"updated_at"`
-		Body      string    `json:"body"`
-		UserId    uuid.UUID `json:"user_id"`
+	response := chirpResponse(chirp)
+	cfg.hydrateChirps(r.Context(), viewerId, &response)
+	jsonResponse(w, http.StatusOK, response)
+}
+
+func (cfg *apiConfig) handlerEditChirp(w http.ResponseWriter, r *http.Request) {
+	requestParams := struct {
+		Body string `json:"body"`
+	}{}
+
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&requestParams); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		errorResponse(w, http.StatusBadRequest, "Error decoding request body")
+		return
+	}
+
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chirpId, err := uuid.Parse(r.PathValue("chirpId"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Could not use chirp id")
+		return
 	}
 
-	response := chirpResponse{
-		Id:        chirp.ID,
-		CreatedAt: chirp.CreatedAt,
-		UpdatedAt: chirp.UpdatedAt,
+	user, err := cfg.dbQueries.GetUser(r.Context(), userId)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	cleanChirp, err := validateChirpBody(requestParams.Body, cfg.chirpRules(user))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		log.Printf("Error starting transaction! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to edit chirp")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	chirp, err := qtx.GetChirpForUpdate(r.Context(), chirpId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errorResponse(w, http.StatusNotFound, "Chirp not found")
+			return
+		}
+		log.Printf("Error getting chirp! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to edit chirp")
+		return
+	}
+
+	if chirp.DeletedAt.Valid {
+		errorResponse(w, http.StatusNotFound, "Chirp not found")
+		return
+	}
+
+	if chirp.UserID != userId {
+		errorResponse(w, http.StatusForbidden, "Unable to edit chirp")
+		return
+	}
+
+	if chirp.RechirpOf.Valid {
+		errorResponse(w, http.StatusBadRequest, "Rechirps cannot be edited")
+		return
+	}
+
+	if time.Since(chirp.CreatedAt) > cfg.editWindow {
+		errorResponse(w, http.StatusForbidden, "Chirp can no longer be edited")
+		return
+	}
+
+	if chirp.Body == cleanChirp {
+		cfg.editedChirpResponse(w, r, chirp)
+		return
+	}
+
+	// keep the body being replaced, stamped with when it was written
+	err = qtx.CreateChirpRevision(r.Context(), database.CreateChirpRevisionParams{
+		ChirpID:   chirp.ID,
 		Body:      chirp.Body,
-		UserId:    chirp.UserID,
+		CreatedAt: chirp.UpdatedAt,
+	})
+	if err != nil {
+		log.Printf("Error saving revision! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to edit chirp")
+		return
+	}
+
+	chirp, err = qtx.EditChirp(r.Context(), database.EditChirpParams{
+		ID:   chirp.ID,
+		Body: cleanChirp,
+	})
+	if err != nil {
+		log.Printf("Error editing chirp! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to edit chirp")
+		return
+	}
+
+	if err := saveEntities(r.Context(), qtx, chirp); err != nil {
+		log.Printf("Error saving entities! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to edit chirp")
+		return
 	}
 
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing edit! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to edit chirp")
+		return
+	}
+
+	cfg.editedChirpResponse(w, r, chirp)
+}
+
+func (cfg *apiConfig) editedChirpResponse(w http.ResponseWriter, r *http.Request, chirp database.Chirp) {
+	response := chirpResponse(chirp)
+	cfg.hydrateChirps(r.Context(), uuid.NullUUID{UUID: chirp.UserID, Valid: true}, &response)
+	jsonResponse(w, http.StatusOK, response)
+}
+
+func (cfg *apiConfig) handlerGetChirpHistory(w http.ResponseWriter, r *http.Request) {
+	chirpId, err := uuid.Parse(r.PathValue("chirpId"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Could not use chirp id")
+		return
+	}
+
+	viewerId, err := cfg.viewer(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chirp, err := cfg.dbQueries.GetChirpForViewer(r.Context(), database.GetChirpForViewerParams{
+		ID:       chirpId,
+		ViewerID: viewerId,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errorResponse(w, http.StatusNotFound, "Chirp not found")
+			return
+		}
+		log.Printf("Error getting chirp! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to retrieve chirp")
+		return
+	}
+
+	revisions, err := cfg.dbQueries.GetChirpRevisions(r.Context(), chirp.ID)
+	if err != nil {
+		log.Printf("Error getting revisions! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to retrieve chirp history")
+		return
+	}
+
+	response := []ChirpRevision{{Body: chirp.Body, CreatedAt: chirp.UpdatedAt}}
+	for _, revision := range revisions {
+		response = append(response, ChirpRevision{Body: revision.Body, CreatedAt: revision.CreatedAt})
+	}
 	jsonResponse(w, http.StatusOK, response)
 }
 
@@ -192,33 +783,347 @@ func (cfg *apiConfig) handlerDeleteChirp(w http.ResponseWriter, r *http.Request)
 
 	}
 
-	chirp, err := cfg.dbQueries.GetChirp(r.Context(), chirpId)
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		log.Printf("Error starting transaction! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to delete chirp")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	chirp, err := qtx.GetChirpForUpdate(r.Context(), chirpId)
 	if err != nil {
 		log.Printf("Error getting chirp! %v", err)
 		errorResponse(w, http.StatusNotFound, "Unable to retrieve chirp")
 		return
 	}
 
+	if chirp.DeletedAt.Valid {
+		errorResponse(w, http.StatusNotFound, "Unable to retrieve chirp")
+		return
+	}
+
 	if chirp.UserID != userId {
 		log.Printf("Can't do that")
 		errorResponse(w, http.StatusForbidden, "Unable to delete chirp")
 		return
 
 	}
-	args := database.DeleteChirpParams{
-		ID:     chirpId,
-		UserID: userId,
+
+	// rechirps have nothing worth restoring, and leaving one in the trash
+	// would stop the user rechirping the same chirp again
+	if chirp.RechirpOf.Valid {
+		err = qtx.DeleteChirp(r.Context(), database.DeleteChirpParams{
+			ID:     chirpId,
+			UserID: userId,
+		})
+	} else {
+		err = qtx.SoftDeleteChirp(r.Context(), chirpId)
 	}
-	err = cfg.dbQueries.DeleteChirp(r.Context(), args)
 	if err != nil {
 		log.Printf("Error deleting chirp! %v", err)
 		errorResponse(w, http.StatusInternalServerError, "Unable to retrieve chirp")
 		return
 	}
 
+	if err := qtx.DeleteChirpPins(r.Context(), chirpId); err != nil {
+		log.Printf("Error unpinning chirp! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to delete chirp")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing delete! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to delete chirp")
+		return
+	}
+
 	jsonResponse(w, http.StatusNoContent, struct{}{})
 }
 
+func (cfg *apiConfig) handlerGetThread(w http.ResponseWriter, r *http.Request) {
+	chirpId, err := uuid.Parse(r.PathValue("chirpId"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Could not use chirp id")
+		return
+	}
+
+	viewerId, err := cfg.viewer(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	page, err := pagination.ParseQuery(r.URL.Query())
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	focus, err := cfg.dbQueries.GetThreadChirp(r.Context(), database.GetThreadChirpParams{
+		ID:       chirpId,
+		ViewerID: viewerId,
+	})
+	if err != nil || focus.Hidden {
+		if err == nil || err == sql.ErrNoRows {
+			errorResponse(w, http.StatusNotFound, "Chirp not found")
+			return
+		}
+		log.Printf("Error getting chirp! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to retrieve thread")
+		return
+	}
+
+	ancestors, err := cfg.dbQueries.GetChirpAncestors(r.Context(), database.GetChirpAncestorsParams{
+		ID:       chirpId,
+		ViewerID: viewerId,
+	})
+	if err != nil {
+		log.Printf("Error getting ancestors! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to retrieve thread")
+		return
+	}
+
+	args := database.GetChirpDescendantsParams{
+		ID:         chirpId,
+		ViewerID:   viewerId,
+		MaxResults: page.Limit + 1,
+	}
+	args.AfterCreatedAt, args.AfterID = cursorArgs(page.Cursor)
+
+	descendants, err := cfg.dbQueries.GetChirpDescendants(r.Context(), args)
+	if err != nil {
+		log.Printf("Error getting replies! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to retrieve thread")
+		return
+	}
+
+	descendants, nextCursor := paginate(descendants, page.Limit, func(row database.GetChirpDescendantsRow) pagination.Cursor {
+		return pagination.Cursor{Time: row.Chirp.CreatedAt, ID: row.Chirp.ID}
+	})
+
+	thread := Thread{
+		Ancestors: []Chirp{},
+		Chirp:     threadChirpResponse(focus.Chirp, focus.Hidden),
+		Replies:   []ThreadReply{},
+	}
+	for _, row := range ancestors {
+		thread.Ancestors = append(thread.Ancestors, threadChirpResponse(row.Chirp, row.Hidden))
+	}
+	for _, row := range descendants {
+		thread.Replies = append(thread.Replies, ThreadReply{
+			Chirp: threadChirpResponse(row.Chirp, row.Hidden),
+			Depth: row.Depth,
+		})
+	}
+
+	chirps := []*Chirp{&thread.Chirp}
+	for i := range thread.Ancestors {
+		chirps = append(chirps, &thread.Ancestors[i])
+	}
+	for i := range thread.Replies {
+		chirps = append(chirps, &thread.Replies[i].Chirp)
+	}
+	cfg.hydrateChirps(r.Context(), viewerId, chirps...)
+
+	pageResponse(w, r, thread, nextCursor)
+}
+
+// chirpPageResponse writes a page of chirps. Any leading chirps are already
+// hydrated and go ahead of the page without counting towards its limit.
+func (cfg *apiConfig) chirpPageResponse(w http.ResponseWriter, r *http.Request, viewerId uuid.NullUUID, chirps []database.Chirp, limit int32, leading ...Chirp) {
+	chirps, nextCursor := paginate(chirps, limit, func(chirp database.Chirp) pagination.Cursor {
+		return pagination.Cursor{Time: chirp.CreatedAt, ID: chirp.ID}
+	})
+
+	// chirps already leading the page aren't repeated further down
+	leadingIds := map[uuid.UUID]bool{}
+	for _, chirp := range leading {
+		leadingIds[chirp.Id] = true
+	}
+
+	response := []Chirp{}
+	for _, chirp := range chirps {
+		if !leadingIds[chirp.ID] {
+			response = append(response, chirpResponse(chirp))
+		}
+	}
+	cfg.hydrateChirps(r.Context(), viewerId, pointers(response)...)
+	pageResponse(w, r, append(leading, response...), nextCursor)
+}
+
+// hydrateChirps fills in the embedded and viewer-specific parts of chirp
+// responses with a fixed number of queries per page rather than per chirp.
+func (cfg *apiConfig) hydrateChirps(ctx context.Context, viewerId uuid.NullUUID, chirps ...*Chirp) {
+	chirps = append(chirps, cfg.embedChirps(ctx, viewerId, chirps)...)
+	if err := cfg.attachMentions(ctx, chirps); err != nil {
+		log.Printf("Error getting mentions! %v", err)
+	}
+	if err := cfg.attachChirpMedia(ctx, chirps); err != nil {
+		log.Printf("Error getting media! %v", err)
+	}
+	if err := cfg.attachPolls(ctx, viewerId, chirps); err != nil {
+		log.Printf("Error getting polls! %v", err)
+	}
+	cfg.markLikedChirps(ctx, viewerId, chirps)
+	cfg.markBookmarkedChirps(ctx, viewerId, chirps)
+	cfg.applyContentPreferences(ctx, viewerId, chirps)
+}
+
+// embedChirps resolves rechirp and quote references. Anything deleted or
+// hidden from the viewer is replaced with an unavailable stub.
+func (cfg *apiConfig) embedChirps(ctx context.Context, viewerId uuid.NullUUID, chirps []*Chirp) []*Chirp {
+	ids := []uuid.UUID{}
+	for _, chirp := range chirps {
+		if chirp.RechirpOfId != nil {
+			ids = append(ids, *chirp.RechirpOfId)
+		}
+		if chirp.QuoteOfId != nil {
+			ids = append(ids, *chirp.QuoteOfId)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	rows, err := cfg.dbQueries.GetEmbeddedChirps(ctx, database.GetEmbeddedChirpsParams{
+		ViewerID: viewerId,
+		Ids:      ids,
+	})
+	if err != nil {
+		log.Printf("Error getting embedded chirps! %v", err)
+		return nil
+	}
+
+	found := map[uuid.UUID]database.GetEmbeddedChirpsRow{}
+	for _, row := range rows {
+		found[row.Chirp.ID] = row
+	}
+	embed := func(id uuid.UUID) *Chirp {
+		row, ok := found[id]
+		if !ok || row.Hidden || row.Chirp.DeletedAt.Valid {
+			return &Chirp{Id: id, Unavailable: true}
+		}
+		response := chirpResponse(row.Chirp)
+		return &response
+	}
+
+	embedded := []*Chirp{}
+	for _, chirp := range chirps {
+		if chirp.RechirpOfId != nil {
+			chirp.RechirpOf = embed(*chirp.RechirpOfId)
+			embedded = append(embedded, chirp.RechirpOf)
+		}
+		if chirp.QuoteOfId != nil {
+			chirp.QuoteOf = embed(*chirp.QuoteOfId)
+			embedded = append(embedded, chirp.QuoteOf)
+		}
+	}
+	return embedded
+}
+
+func (cfg *apiConfig) markLikedChirps(ctx context.Context, viewerId uuid.NullUUID, chirps []*Chirp) {
+	if !viewerId.Valid {
+		return
+	}
+
+	ids := []uuid.UUID{}
+	for _, chirp := range chirps {
+		if !chirp.Deleted && !chirp.Unavailable {
+			ids = append(ids, chirp.Id)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+
+	liked, err := cfg.dbQueries.GetLikedChirpIds(ctx, database.GetLikedChirpIdsParams{
+		UserID:   viewerId.UUID,
+		ChirpIds: ids,
+	})
+	if err != nil {
+		log.Printf("Error getting liked chirps! %v", err)
+		return
+	}
+
+	for _, chirp := range chirps {
+		if chirp.Deleted || chirp.Unavailable {
+			continue
+		}
+		likedByMe := slices.Contains(liked, chirp.Id)
+		chirp.LikedByMe = &likedByMe
+	}
+}
+
+func pointers[T any](items []T) []*T {
+	result := make([]*T, len(items))
+	for i := range items {
+		result[i] = &items[i]
+	}
+	return result
+}
+
+// validateChirpBody applies the rules every chirp body has to pass and
+// returns the cleaned body to store.
+func validateChirpBody(body string, rules chirptext.Rules) (string, error) {
+	body = chirptext.Normalize(body)
+	if !rules.Allows(body) {
+		return "", errChirpTooLong
+	}
+	return badWordReplacement(body), nil
+}
+
+// parseVisibility checks a requested visibility, defaulting to public.
+func parseVisibility(visibility string) (string, error) {
+	switch visibility {
+	case "":
+		return "public", nil
+	case "public", "unlisted", "followers", "mentioned":
+		return visibility, nil
+	}
+	return "", errors.New("visibility must be public, unlisted, followers or mentioned")
+}
+
+// chirpRules picks the length limit for the user's plan.
+func (cfg *apiConfig) chirpRules(user database.User) chirptext.Rules {
+	if user.IsChirpyRed {
+		return chirptext.NewRules(cfg.maxRedChirpLength)
+	}
+	return chirptext.NewRules(cfg.maxChirpLength)
+}
+
+// handlerGetChirpRules tells clients how chirps are measured so they can
+// count along as the user types. max_length is the viewer's own limit.
+func (cfg *apiConfig) handlerGetChirpRules(w http.ResponseWriter, r *http.Request) {
+	viewerId, err := cfg.viewer(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	rules := chirptext.NewRules(cfg.maxChirpLength)
+	if viewerId.Valid {
+		user, err := cfg.dbQueries.GetUser(r.Context(), viewerId.UUID)
+		if err != nil {
+			errorResponse(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		rules = cfg.chirpRules(user)
+	}
+
+	jsonResponse(w, http.StatusOK, struct {
+		chirptext.Rules
+		Plans map[string]int `json:"plans"`
+	}{
+		Rules: rules,
+		Plans: map[string]int{
+			"free":       cfg.maxChirpLength,
+			"chirpy_red": cfg.maxRedChirpLength,
+		},
+	})
+}
+
 func badWordReplacement(s string) string {
 	badWords := []string{"kerfuffle", "sharbert", "fornax"}
 	var cleanWords []string
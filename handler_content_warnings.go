@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/geophpherie/boot-dev-chirpy-v2/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	maxContentWarningLength = 100
+	defaultSensitiveContent = "blur"
+)
+
+func parseContentWarning(warning string) (sql.NullString, error) {
+	cw := optionalString(warning)
+	if utf8.RuneCountInString(cw.String) > maxContentWarningLength {
+		return sql.NullString{}, fmt.Errorf("content_warning can be at most %v characters", maxContentWarningLength)
+	}
+	return cw, nil
+}
+
+func parseSensitiveContent(preference string) error {
+	switch preference {
+	case "hide", "blur", "show":
+		return nil
+	}
+	return errors.New("sensitive_content must be hide, blur or show")
+}
+
+// applyContentPreferences tells clients how to present chirps that carry a
+// content warning or are marked sensitive, following the viewer's setting.
+// Authors always see their own chirps as they are.
+func (cfg *apiConfig) applyContentPreferences(ctx context.Context, viewerId uuid.NullUUID, chirps []*Chirp) {
+	flagged := []*Chirp{}
+	for _, chirp := range chirps {
+		if chirp.Sensitive || chirp.ContentWarning != "" {
+			flagged = append(flagged, chirp)
+		}
+	}
+	if len(flagged) == 0 {
+		return
+	}
+
+	preference := defaultSensitiveContent
+	if viewerId.Valid {
+		viewer, err := cfg.dbQueries.GetUser(ctx, viewerId.UUID)
+		if err != nil {
+			log.Printf("Error getting viewer! %v", err)
+		} else {
+			preference = viewer.SensitiveContent
+		}
+	}
+
+	for _, chirp := range flagged {
+		if viewerId.Valid && chirp.UserId == viewerId.UUID {
+			chirp.Display = "show"
+		} else {
+			chirp.Display = preference
+		}
+	}
+}
+
+// handlerAdminSetSensitive lets moderators force a chirp to be treated as
+// sensitive, or lift that again. The author's own flag is left alone.
+func (cfg *apiConfig) handlerAdminSetSensitive(w http.ResponseWriter, r *http.Request) {
+	requestParams := struct {
+		Sensitive bool `json:"sensitive"`
+	}{}
+
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&requestParams); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		errorResponse(w, http.StatusBadRequest, "Error decoding request body")
+		return
+	}
+
+	if _, err := cfg.authenticateAdmin(r); err != nil {
+		errorResponse(w, http.StatusForbidden, "forbidden")
+		return
+	}
+
+	chirpId, err := uuid.Parse(r.PathValue("chirpId"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Could not use chirp id")
+		return
+	}
+
+	chirp, err := cfg.dbQueries.SetChirpSensitiveForced(r.Context(), database.SetChirpSensitiveForcedParams{
+		ID:              chirpId,
+		SensitiveForced: requestParams.Sensitive,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errorResponse(w, http.StatusNotFound, "Chirp not found")
+			return
+		}
+		log.Printf("Error flagging chirp! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not update chirp")
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, deletedChirpResponse(chirp))
+}
@@ -0,0 +1,387 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/geophpherie/boot-dev-chirpy-v2/internal/database"
+	"github.com/geophpherie/boot-dev-chirpy-v2/internal/pagination"
+	"github.com/google/uuid"
+)
+
+// maxDraftLength is deliberately generous: drafts only have to fit the
+// chirp length limit once they're published.
+const maxDraftLength = 5000
+
+type Draft struct {
+	Id             uuid.UUID   `json:"id"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+	Body           string      `json:"body"`
+	InReplyTo      *uuid.UUID  `json:"in_reply_to,omitempty"`
+	QuoteOfId      *uuid.UUID  `json:"quote_of_id,omitempty"`
+	MediaIds       []uuid.UUID `json:"media_ids"`
+	Visibility     string      `json:"visibility"`
+	ContentWarning string      `json:"content_warning,omitempty"`
+	Sensitive      bool        `json:"sensitive"`
+	Version        int32       `json:"version"`
+}
+
+func draftResponse(draft database.Draft) Draft {
+	response := Draft{
+		Id:             draft.ID,
+		CreatedAt:      draft.CreatedAt,
+		UpdatedAt:      draft.UpdatedAt,
+		Body:           draft.Body,
+		MediaIds:       draft.MediaIds,
+		Visibility:     draft.Visibility,
+		ContentWarning: draft.ContentWarning.String,
+		Sensitive:      draft.Sensitive,
+		Version:        draft.Version,
+	}
+	if response.MediaIds == nil {
+		response.MediaIds = []uuid.UUID{}
+	}
+	if draft.InReplyTo.Valid {
+		response.InReplyTo = &draft.InReplyTo.UUID
+	}
+	if draft.QuoteOf.Valid {
+		response.QuoteOfId = &draft.QuoteOf.UUID
+	}
+	return response
+}
+
+func nullUUID(id *uuid.UUID) uuid.NullUUID {
+	if id == nil {
+		return uuid.NullUUID{}
+	}
+	return uuid.NullUUID{UUID: *id, Valid: true}
+}
+
+func validateDraft(input *chirpInput) error {
+	if utf8.RuneCountInString(input.Body) > maxDraftLength {
+		return errors.New("Draft is too long")
+	}
+	visibility, err := parseVisibility(input.Visibility)
+	if err != nil {
+		return err
+	}
+	input.Visibility = visibility
+	if _, err := parseContentWarning(input.ContentWarning); err != nil {
+		return err
+	}
+	// media_ids can't be NULL, and pq sends a nil slice as NULL
+	if input.MediaIds == nil {
+		input.MediaIds = []uuid.UUID{}
+	}
+	return validateMediaIds(input.MediaIds)
+}
+
+func (cfg *apiConfig) handlerNewDraft(w http.ResponseWriter, r *http.Request) {
+	requestParams := chirpInput{}
+
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&requestParams); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		errorResponse(w, http.StatusBadRequest, "Error decoding request body")
+		return
+	}
+
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if err := validateDraft(&requestParams); err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	draft, err := cfg.dbQueries.CreateDraft(r.Context(), database.CreateDraftParams{
+		UserID:         userId,
+		Body:           requestParams.Body,
+		InReplyTo:      nullUUID(requestParams.InReplyTo),
+		QuoteOf:        nullUUID(requestParams.QuoteOf),
+		MediaIds:       requestParams.MediaIds,
+		Visibility:     requestParams.Visibility,
+		ContentWarning: optionalString(requestParams.ContentWarning),
+		Sensitive:      requestParams.Sensitive,
+	})
+	if err != nil {
+		log.Printf("Error creating draft! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not create draft")
+		return
+	}
+
+	jsonResponse(w, http.StatusCreated, draftResponse(draft))
+}
+
+func (cfg *apiConfig) handlerGetDrafts(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	page, err := pagination.ParseQuery(r.URL.Query())
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	args := database.GetDraftsParams{
+		UserID:     userId,
+		MaxResults: page.Limit + 1,
+	}
+	args.BeforeUpdatedAt, args.BeforeID = cursorArgs(page.Cursor)
+
+	drafts, err := cfg.dbQueries.GetDrafts(r.Context(), args)
+	if err != nil {
+		log.Printf("Error getting drafts! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not retrieve drafts")
+		return
+	}
+
+	drafts, nextCursor := paginate(drafts, page.Limit, func(draft database.Draft) pagination.Cursor {
+		return pagination.Cursor{Time: draft.UpdatedAt, ID: draft.ID}
+	})
+
+	response := []Draft{}
+	for _, draft := range drafts {
+		response = append(response, draftResponse(draft))
+	}
+	pageResponse(w, r, response, nextCursor)
+}
+
+func (cfg *apiConfig) handlerGetDraft(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	draftId, err := uuid.Parse(r.PathValue("draftId"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Could not use draft id")
+		return
+	}
+
+	draft, err := cfg.dbQueries.GetDraft(r.Context(), database.GetDraftParams{ID: draftId, UserID: userId})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errorResponse(w, http.StatusNotFound, "Draft not found")
+			return
+		}
+		log.Printf("Error getting draft! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not retrieve draft")
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, draftResponse(draft))
+}
+
+// handlerUpdateDraft replaces a draft's contents. The client must send the
+// version it last saw; if another device has saved since, the update is
+// refused with 409 and the current draft so the client can reconcile.
+func (cfg *apiConfig) handlerUpdateDraft(w http.ResponseWriter, r *http.Request) {
+	requestParams := struct {
+		chirpInput
+		Version int32 `json:"version"`
+	}{}
+
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&requestParams); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		errorResponse(w, http.StatusBadRequest, "Error decoding request body")
+		return
+	}
+
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	draftId, err := uuid.Parse(r.PathValue("draftId"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Could not use draft id")
+		return
+	}
+
+	if requestParams.Version == 0 {
+		errorResponse(w, http.StatusBadRequest, "version is required")
+		return
+	}
+	if err := validateDraft(&requestParams.chirpInput); err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	draft, err := cfg.dbQueries.UpdateDraft(r.Context(), database.UpdateDraftParams{
+		ID:             draftId,
+		UserID:         userId,
+		Version:        requestParams.Version,
+		Body:           requestParams.Body,
+		InReplyTo:      nullUUID(requestParams.InReplyTo),
+		QuoteOf:        nullUUID(requestParams.QuoteOf),
+		MediaIds:       requestParams.MediaIds,
+		Visibility:     requestParams.Visibility,
+		ContentWarning: optionalString(requestParams.ContentWarning),
+		Sensitive:      requestParams.Sensitive,
+	})
+	if err == sql.ErrNoRows {
+		current, err := cfg.dbQueries.GetDraft(r.Context(), database.GetDraftParams{ID: draftId, UserID: userId})
+		if err != nil {
+			errorResponse(w, http.StatusNotFound, "Draft not found")
+			return
+		}
+		jsonResponse(w, http.StatusConflict, struct {
+			Error string `json:"error"`
+			Draft Draft  `json:"draft"`
+		}{
+			Error: "Draft has been changed elsewhere",
+			Draft: draftResponse(current),
+		})
+		return
+	}
+	if err != nil {
+		log.Printf("Error updating draft! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not update draft")
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, draftResponse(draft))
+}
+
+func (cfg *apiConfig) handlerDeleteDraft(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	draftId, err := uuid.Parse(r.PathValue("draftId"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Could not use draft id")
+		return
+	}
+
+	deleted, err := cfg.dbQueries.DeleteDraft(r.Context(), database.DeleteDraftParams{ID: draftId, UserID: userId})
+	if err != nil {
+		log.Printf("Error deleting draft! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not delete draft")
+		return
+	}
+	if deleted == 0 {
+		errorResponse(w, http.StatusNotFound, "Draft not found")
+		return
+	}
+
+	jsonResponse(w, http.StatusNoContent, struct{}{})
+}
+
+// handlerPublishDraft turns a draft into a chirp through the same checks
+// as handlerNewChirp. The draft is removed in the same transaction, and
+// only if nothing has saved over it in the meantime.
+func (cfg *apiConfig) handlerPublishDraft(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	draftId, err := uuid.Parse(r.PathValue("draftId"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Could not use draft id")
+		return
+	}
+
+	user, err := cfg.dbQueries.GetUser(r.Context(), userId)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	draft, err := cfg.dbQueries.GetDraft(r.Context(), database.GetDraftParams{ID: draftId, UserID: userId})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errorResponse(w, http.StatusNotFound, "Draft not found")
+			return
+		}
+		log.Printf("Error getting draft! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not publish draft")
+		return
+	}
+
+	input := chirpInput{
+		Body:           draft.Body,
+		MediaIds:       draft.MediaIds,
+		Visibility:     draft.Visibility,
+		ContentWarning: draft.ContentWarning.String,
+		Sensitive:      draft.Sensitive,
+	}
+	if draft.InReplyTo.Valid {
+		input.InReplyTo = &draft.InReplyTo.UUID
+	}
+	if draft.QuoteOf.Valid {
+		input.QuoteOf = &draft.QuoteOf.UUID
+	}
+
+	args, status, err := cfg.prepareChirp(r.Context(), user, input)
+	if err != nil {
+		errorResponse(w, status, err.Error())
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		log.Printf("Error starting transaction! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not publish draft")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	chirp, err := insertChirp(r.Context(), qtx, args, draft.MediaIds)
+	if err != nil {
+		if errors.Is(err, errMediaUnavailable) || errors.Is(err, errMediaMixed) {
+			errorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		log.Printf("Error creating chirp! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not publish draft")
+		return
+	}
+
+	deleted, err := qtx.DeleteDraftVersion(r.Context(), database.DeleteDraftVersionParams{
+		ID:      draft.ID,
+		UserID:  userId,
+		Version: draft.Version,
+	})
+	if err != nil {
+		log.Printf("Error deleting draft! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not publish draft")
+		return
+	}
+	if deleted == 0 {
+		errorResponse(w, http.StatusConflict, "Draft has been changed elsewhere")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing draft publish! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not publish draft")
+		return
+	}
+	cfg.publishChirp(r.Context(), chirp)
+
+	response := chirpResponse(chirp)
+	cfg.hydrateChirps(r.Context(), uuid.NullUUID{UUID: userId, Valid: true}, &response)
+	jsonResponse(w, http.StatusCreated, response)
+}
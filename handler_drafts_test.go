@@ -0,0 +1,25 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestValidateDraftWithoutMedia(t *testing.T) {
+	input := chirpInput{Body: "just words"}
+	if err := validateDraft(&input); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	value, err := pq.Array(input.MediaIds).Value()
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if value != "{}" {
+		t.Errorf("expected media_ids to be sent as an empty array, got %v", value)
+	}
+	if input.Visibility != "public" {
+		t.Errorf("expected visibility to default to public, got %q", input.Visibility)
+	}
+}
@@ -0,0 +1,328 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/geophpherie/boot-dev-chirpy-v2/internal/auth"
+	"github.com/geophpherie/boot-dev-chirpy-v2/internal/database"
+	"github.com/geophpherie/boot-dev-chirpy-v2/internal/export"
+	"github.com/google/uuid"
+)
+
+const (
+	exportBatchSize    = 500
+	exportLifetime     = 24 * time.Hour
+	exportClaimTimeout = 30 * time.Minute
+)
+
+type DataExport struct {
+	Id          uuid.UUID  `json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	Status      string     `json:"status"`
+	DownloadUrl string     `json:"download_url,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+func dataExportResponse(dataExport database.DataExport) DataExport {
+	response := DataExport{
+		Id:        dataExport.ID,
+		CreatedAt: dataExport.CreatedAt,
+		Status:    dataExport.Status,
+		ExpiresAt: nullTime(dataExport.ExpiresAt),
+	}
+	if dataExport.Status == "complete" && dataExport.DownloadToken.Valid {
+		response.DownloadUrl = fmt.Sprintf("/api/exports/%v/download?token=%v", dataExport.ID, dataExport.DownloadToken.String)
+	}
+	return response
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func (cfg *apiConfig) handlerNewDataExport(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	dataExport, err := cfg.dbQueries.GetActiveDataExport(r.Context(), userId)
+	if err == nil {
+		jsonResponse(w, http.StatusAccepted, dataExportResponse(dataExport))
+		return
+	}
+	if err != sql.ErrNoRows {
+		log.Printf("Error getting active export! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not start export")
+		return
+	}
+
+	dataExport, err = cfg.dbQueries.CreateDataExport(r.Context(), userId)
+	if err != nil {
+		log.Printf("Error creating export! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not start export")
+		return
+	}
+	cfg.recordSecurityEvent(r, userId, securityEventExportRequest)
+	notify(cfg.exportWake)
+
+	jsonResponse(w, http.StatusAccepted, dataExportResponse(dataExport))
+}
+
+func (cfg *apiConfig) handlerGetDataExport(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	exportId, err := uuid.Parse(r.PathValue("exportId"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Could not use export id")
+		return
+	}
+
+	args := database.GetDataExportParams{
+		ID:     exportId,
+		UserID: userId,
+	}
+	dataExport, err := cfg.dbQueries.GetDataExport(r.Context(), args)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errorResponse(w, http.StatusNotFound, "Export not found")
+			return
+		}
+		log.Printf("Error getting export! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to retrieve export")
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, dataExportResponse(dataExport))
+}
+
+func (cfg *apiConfig) handlerDownloadDataExport(w http.ResponseWriter, r *http.Request) {
+	exportId, err := uuid.Parse(r.PathValue("exportId"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Could not use export id")
+		return
+	}
+
+	args := database.GetDataExportByTokenParams{
+		ID:            exportId,
+		DownloadToken: sql.NullString{String: r.URL.Query().Get("token"), Valid: true},
+	}
+	dataExport, err := cfg.dbQueries.GetDataExportByToken(r.Context(), args)
+	if err != nil || !dataExport.FilePath.Valid {
+		errorResponse(w, http.StatusNotFound, "Export not found")
+		return
+	}
+
+	w.Header().Set("Content-Disposition", `attachment; filename="chirpy-export.zip"`)
+	http.ServeFile(w, r, dataExport.FilePath.String)
+}
+
+func (cfg *apiConfig) processDataExports(ctx context.Context) error {
+	expired, err := cfg.dbQueries.DeleteExpiredDataExports(ctx)
+	if err != nil {
+		return err
+	}
+	for _, path := range expired {
+		if path.Valid {
+			os.Remove(path.String)
+		}
+	}
+
+	for {
+		dataExport, err := cfg.dbQueries.ClaimDataExport(ctx, exportClaimTimeout.Seconds())
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		path, err := cfg.buildDataExport(ctx, dataExport.UserID, dataExport.ID)
+		if err != nil {
+			log.Printf("Error building export %v :: %v", dataExport.ID, err)
+			if err := cfg.dbQueries.FailDataExport(ctx, dataExport.ID); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := cfg.completeDataExport(ctx, dataExport.ID, path); err != nil {
+			log.Printf("Error completing export %v :: %v", dataExport.ID, err)
+			os.Remove(path)
+			if err := cfg.dbQueries.FailDataExport(ctx, dataExport.ID); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (cfg *apiConfig) completeDataExport(ctx context.Context, exportId uuid.UUID, path string) error {
+	token, err := auth.MakeRefreshToken()
+	if err != nil {
+		return err
+	}
+
+	args := database.CompleteDataExportParams{
+		ID:            exportId,
+		FilePath:      sql.NullString{String: path, Valid: true},
+		DownloadToken: sql.NullString{String: token, Valid: true},
+		ExpiresAt:     sql.NullTime{Time: time.Now().Add(exportLifetime), Valid: true},
+	}
+	return cfg.dbQueries.CompleteDataExport(ctx, args)
+}
+
+func (cfg *apiConfig) buildDataExport(ctx context.Context, userId, exportId uuid.UUID) (path string, err error) {
+	if err := os.MkdirAll(cfg.exportDir, 0o700); err != nil {
+		return "", err
+	}
+
+	path = filepath.Join(cfg.exportDir, exportId.String()+".zip")
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(path)
+		}
+	}()
+
+	archive := export.NewArchive(f)
+	if err := cfg.writeDataExport(ctx, archive, userId); err != nil {
+		return "", err
+	}
+	return path, archive.Close()
+}
+
+func (cfg *apiConfig) writeDataExport(ctx context.Context, archive *export.Archive, userId uuid.UUID) error {
+	user, err := cfg.dbQueries.GetUser(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	if err := archive.WriteJSON("profile.json", userResponse(user)); err != nil {
+		return err
+	}
+
+	chirpsJson, err := archive.CreateJSONArray("chirps.json")
+	if err != nil {
+		return err
+	}
+	err = cfg.forEachChirpByUser(ctx, userId, func(chirp database.Chirp) error {
+		return chirpsJson.Append(chirpResponse(chirp))
+	})
+	if err != nil {
+		return err
+	}
+	if err := chirpsJson.Close(); err != nil {
+		return err
+	}
+
+	chirpsCsv, err := archive.CreateCSV("chirps.csv", []string{"id", "created_at", "updated_at", "body"})
+	if err != nil {
+		return err
+	}
+	err = cfg.forEachChirpByUser(ctx, userId, func(chirp database.Chirp) error {
+		return chirpsCsv.Write([]string{
+			chirp.ID.String(),
+			chirp.CreatedAt.Format(time.RFC3339),
+			chirp.UpdatedAt.Format(time.RFC3339),
+			chirp.Body,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	chirpsCsv.Flush()
+	if err := chirpsCsv.Error(); err != nil {
+		return err
+	}
+
+	refreshTokens, err := cfg.dbQueries.GetRefreshTokensByUserId(ctx, userId)
+	if err != nil {
+		return err
+	}
+	type session struct {
+		CreatedAt *time.Time `json:"created_at"`
+		ExpiresAt *time.Time `json:"expires_at"`
+		RevokedAt *time.Time `json:"revoked_at"`
+	}
+	sessions := []session{}
+	for _, token := range refreshTokens {
+		sessions = append(sessions, session{
+			CreatedAt: nullTime(token.CreatedAt),
+			ExpiresAt: nullTime(token.ExpiresAt),
+			RevokedAt: nullTime(token.RevokedAt),
+		})
+	}
+	if err := archive.WriteJSON("sessions.json", sessions); err != nil {
+		return err
+	}
+
+	events, err := cfg.dbQueries.GetSecurityEventsByUserId(ctx, userId)
+	if err != nil {
+		return err
+	}
+	type securityEvent struct {
+		CreatedAt time.Time `json:"created_at"`
+		Event     string    `json:"event"`
+		IpAddress string    `json:"ip_address"`
+		UserAgent string    `json:"user_agent"`
+	}
+	securityEvents := []securityEvent{}
+	for _, event := range events {
+		securityEvents = append(securityEvents, securityEvent{
+			CreatedAt: event.CreatedAt,
+			Event:     event.Event,
+			IpAddress: event.IpAddress,
+			UserAgent: event.UserAgent,
+		})
+	}
+	return archive.WriteJSON("security_events.json", securityEvents)
+}
+
+// forEachChirpByUser pages through a user's chirps in batches instead of
+// loading them all at once.
+func (cfg *apiConfig) forEachChirpByUser(ctx context.Context, userId uuid.UUID, fn func(database.Chirp) error) error {
+	args := database.GetChirpsByUserIdAfterParams{
+		UserID:     userId,
+		MaxResults: exportBatchSize,
+	}
+	for {
+		chirps, err := cfg.dbQueries.GetChirpsByUserIdAfter(ctx, args)
+		if err != nil {
+			return fmt.Errorf("reading chirps: %w", err)
+		}
+
+		for _, chirp := range chirps {
+			if err := fn(chirp); err != nil {
+				return err
+			}
+		}
+
+		if len(chirps) < exportBatchSize {
+			return nil
+		}
+		last := chirps[len(chirps)-1]
+		args.AfterCreatedAt = last.CreatedAt
+		args.AfterID = last.ID
+	}
+}
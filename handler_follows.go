@@ -0,0 +1,314 @@
+package main
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/geophpherie/boot-dev-chirpy-v2/internal/database"
+	"github.com/geophpherie/boot-dev-chirpy-v2/internal/pagination"
+	"github.com/google/uuid"
+)
+
+type Follow struct {
+	FollowerId uuid.UUID `json:"follower_id"`
+	FolloweeId uuid.UUID `json:"followee_id"`
+	CreatedAt  time.Time `json:"created_at"`
+	Status     string    `json:"status"`
+}
+
+type FollowEntry struct {
+	Profile
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+func (cfg *apiConfig) handlerFollow(w http.ResponseWriter, r *http.Request) {
+	followerId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	followeeId, err := uuid.Parse(r.PathValue("userId"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Could not use user id")
+		return
+	}
+
+	if followerId == followeeId {
+		errorResponse(w, http.StatusBadRequest, "Cannot follow yourself")
+		return
+	}
+
+	blocked, err := cfg.dbQueries.IsBlocked(r.Context(), database.IsBlockedParams{
+		UserID:  followerId,
+		OtherID: followeeId,
+	})
+	if err != nil {
+		log.Printf("Error checking blocks! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to follow user")
+		return
+	}
+	if blocked {
+		errorResponse(w, http.StatusForbidden, "Unable to follow user")
+		return
+	}
+
+	followee, err := cfg.dbQueries.GetUser(r.Context(), followeeId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errorResponse(w, http.StatusNotFound, "User not found")
+			return
+		}
+		log.Printf("Error getting user! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to follow user")
+		return
+	}
+	// pending and rejected registrations can't be followed
+	if followee.Status != "active" {
+		errorResponse(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	status := "accepted"
+	if followee.RequiresFollowApproval && followee.IsChirpyRed {
+		status = "pending"
+	}
+
+	args := database.CreateFollowParams{
+		FollowerID: followerId,
+		FolloweeID: followeeId,
+		Status:     status,
+	}
+	code := http.StatusCreated
+	follow, err := cfg.dbQueries.CreateFollow(r.Context(), args)
+	if err == sql.ErrNoRows {
+		// already following (or already requested), so just report it
+		code = http.StatusOK
+		follow, err = cfg.dbQueries.GetFollow(r.Context(), database.GetFollowParams{
+			FollowerID: followerId,
+			FolloweeID: followeeId,
+		})
+	}
+	if err != nil {
+		log.Printf("Error creating follow! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to follow user")
+		return
+	}
+
+	if follow.Status == "accepted" {
+		cfg.backfillTimeline(r.Context(), followerId, followeeId)
+	}
+
+	jsonResponse(w, code, Follow{
+		FollowerId: follow.FollowerID,
+		FolloweeId: follow.FolloweeID,
+		CreatedAt:  follow.CreatedAt,
+		Status:     follow.Status,
+	})
+}
+
+func (cfg *apiConfig) handlerUnfollow(w http.ResponseWriter, r *http.Request) {
+	followerId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	followeeId, err := uuid.Parse(r.PathValue("userId"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Could not use user id")
+		return
+	}
+
+	args := database.DeleteFollowParams{
+		FollowerID: followerId,
+		FolloweeID: followeeId,
+	}
+	if _, err := cfg.dbQueries.DeleteFollow(r.Context(), args); err != nil {
+		log.Printf("Error deleting follow! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to unfollow user")
+		return
+	}
+
+	err = cfg.dbQueries.DeleteTimelineEntriesByAuthor(r.Context(), database.DeleteTimelineEntriesByAuthorParams{
+		UserID:   followerId,
+		AuthorID: followeeId,
+	})
+	if err != nil {
+		log.Printf("Error clearing timeline! %v", err)
+	}
+
+	jsonResponse(w, http.StatusNoContent, struct{}{})
+}
+
+func (cfg *apiConfig) handlerGetFollowers(w http.ResponseWriter, r *http.Request) {
+	userId, err := uuid.Parse(r.PathValue("userId"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Could not use user id")
+		return
+	}
+
+	page, err := pagination.ParseQuery(r.URL.Query())
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	args := database.GetFollowersParams{
+		UserID:     userId,
+		MaxResults: page.Limit + 1,
+	}
+	args.BeforeCreatedAt, args.BeforeID = cursorArgs(page.Cursor)
+
+	rows, err := cfg.dbQueries.GetFollowers(r.Context(), args)
+	if err != nil {
+		log.Printf("Error getting followers! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not retrieve followers")
+		return
+	}
+
+	entries := []FollowEntry{}
+	for _, row := range rows {
+		entries = append(entries, FollowEntry{Profile: profileResponse(row.User), FollowedAt: row.FollowedAt})
+	}
+	followPageResponse(w, r, entries, page.Limit)
+}
+
+func (cfg *apiConfig) handlerGetFollowing(w http.ResponseWriter, r *http.Request) {
+	userId, err := uuid.Parse(r.PathValue("userId"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Could not use user id")
+		return
+	}
+
+	page, err := pagination.ParseQuery(r.URL.Query())
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	args := database.GetFollowingParams{
+		UserID:     userId,
+		MaxResults: page.Limit + 1,
+	}
+	args.BeforeCreatedAt, args.BeforeID = cursorArgs(page.Cursor)
+
+	rows, err := cfg.dbQueries.GetFollowing(r.Context(), args)
+	if err != nil {
+		log.Printf("Error getting following! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not retrieve following")
+		return
+	}
+
+	entries := []FollowEntry{}
+	for _, row := range rows {
+		entries = append(entries, FollowEntry{Profile: profileResponse(row.User), FollowedAt: row.FollowedAt})
+	}
+	followPageResponse(w, r, entries, page.Limit)
+}
+
+func (cfg *apiConfig) handlerGetFollowRequests(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	page, err := pagination.ParseQuery(r.URL.Query())
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	args := database.GetFollowRequestsParams{
+		UserID:     userId,
+		MaxResults: page.Limit + 1,
+	}
+	args.BeforeCreatedAt, args.BeforeID = cursorArgs(page.Cursor)
+
+	rows, err := cfg.dbQueries.GetFollowRequests(r.Context(), args)
+	if err != nil {
+		log.Printf("Error getting follow requests! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not retrieve follow requests")
+		return
+	}
+
+	entries := []FollowEntry{}
+	for _, row := range rows {
+		entries = append(entries, FollowEntry{Profile: profileResponse(row.User), FollowedAt: row.FollowedAt})
+	}
+	followPageResponse(w, r, entries, page.Limit)
+}
+
+func (cfg *apiConfig) handlerApproveFollowRequest(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	followerId, err := uuid.Parse(r.PathValue("userId"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Could not use user id")
+		return
+	}
+
+	args := database.AcceptFollowParams{
+		FollowerID: followerId,
+		FolloweeID: userId,
+	}
+	accepted, err := cfg.dbQueries.AcceptFollow(r.Context(), args)
+	if err != nil {
+		log.Printf("Error accepting follow! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to approve follow request")
+		return
+	}
+	if accepted == 0 {
+		errorResponse(w, http.StatusNotFound, "Follow request not found")
+		return
+	}
+	cfg.backfillTimeline(r.Context(), followerId, userId)
+
+	jsonResponse(w, http.StatusNoContent, struct{}{})
+}
+
+func (cfg *apiConfig) handlerRejectFollowRequest(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	followerId, err := uuid.Parse(r.PathValue("userId"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Could not use user id")
+		return
+	}
+
+	args := database.DeleteFollowRequestParams{
+		FollowerID: followerId,
+		FolloweeID: userId,
+	}
+	deleted, err := cfg.dbQueries.DeleteFollowRequest(r.Context(), args)
+	if err != nil {
+		log.Printf("Error deleting follow! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to reject follow request")
+		return
+	}
+	if deleted == 0 {
+		errorResponse(w, http.StatusNotFound, "Follow request not found")
+		return
+	}
+
+	jsonResponse(w, http.StatusNoContent, struct{}{})
+}
+
+func followPageResponse(w http.ResponseWriter, r *http.Request, entries []FollowEntry, limit int32) {
+	entries, nextCursor := paginate(entries, limit, func(entry FollowEntry) pagination.Cursor {
+		return pagination.Cursor{Time: entry.FollowedAt, ID: entry.ID}
+	})
+
+	pageResponse(w, r, entries, nextCursor)
+}
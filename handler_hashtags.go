@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/geophpherie/boot-dev-chirpy-v2/internal/database"
+	"github.com/geophpherie/boot-dev-chirpy-v2/internal/entities"
+	"github.com/geophpherie/boot-dev-chirpy-v2/internal/pagination"
+)
+
+const (
+	trendCount           = 20
+	trendWindow          = 24 * time.Hour
+	trendHalfLife        = 6 * time.Hour
+	trendBaselineWindows = 6
+	trendMinChirps       = 3
+)
+
+type Trend struct {
+	Tag        string  `json:"tag"`
+	ChirpCount int32   `json:"chirp_count"`
+	Score      float64 `json:"score"`
+}
+
+func (cfg *apiConfig) handlerGetHashtagChirps(w http.ResponseWriter, r *http.Request) {
+	tag := entities.NormalizeHashtag(r.PathValue("tag"))
+	if tag == "" {
+		errorResponse(w, http.StatusBadRequest, "Invalid hashtag")
+		return
+	}
+
+	viewerId, err := cfg.viewer(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	page, err := pagination.ParseQuery(r.URL.Query())
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	args := database.GetChirpsByHashtagParams{
+		Tag:        tag,
+		ViewerID:   viewerId,
+		MaxResults: page.Limit + 1,
+	}
+	args.BeforeCreatedAt, args.BeforeID = cursorArgs(page.Cursor)
+
+	chirps, err := cfg.dbQueries.GetChirpsByHashtag(r.Context(), args)
+	if err != nil {
+		log.Printf("Error getting hashtag chirps! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not retrieve chirps")
+		return
+	}
+
+	cfg.chirpPageResponse(w, r, viewerId, chirps, page.Limit)
+}
+
+func (cfg *apiConfig) handlerGetTrends(w http.ResponseWriter, r *http.Request) {
+	rows, err := cfg.dbQueries.GetTrends(r.Context(), trendCount)
+	if err != nil {
+		log.Printf("Error getting trends! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not retrieve trends")
+		return
+	}
+
+	trends := []Trend{}
+	for _, row := range rows {
+		trends = append(trends, Trend{Tag: row.Tag, ChirpCount: row.ChirpCount, Score: row.Score})
+	}
+	jsonResponse(w, http.StatusOK, trends)
+}
+
+// refreshTrends recomputes the trends table. A tag's score is its activity
+// over the last window, decayed by age, divided by its usual rate over the
+// preceding baseline windows, so tags that are spiking beat ones that are
+// merely always popular.
+func (cfg *apiConfig) refreshTrends(ctx context.Context) error {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	if err := qtx.DeleteTrends(ctx); err != nil {
+		return err
+	}
+
+	err = qtx.ComputeTrends(ctx, database.ComputeTrendsParams{
+		HalfLifeSeconds: trendHalfLife.Seconds(),
+		WindowSeconds:   trendWindow.Seconds(),
+		BaselineWindows: trendBaselineWindows,
+		MinChirps:       trendMinChirps,
+		MaxResults:      trendCount,
+	})
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// saveHashtags replaces the hashtags recorded for a chirp with the ones in
+// its current body.
+func saveHashtags(ctx context.Context, qtx *database.Queries, chirp database.Chirp) error {
+	if err := qtx.DeleteChirpHashtags(ctx, chirp.ID); err != nil {
+		return err
+	}
+
+	for _, tag := range entities.Hashtags(chirp.Body) {
+		hashtag, err := qtx.UpsertHashtag(ctx, tag)
+		if err != nil {
+			return err
+		}
+
+		err = qtx.AddChirpHashtag(ctx, database.AddChirpHashtagParams{
+			ChirpID:   chirp.ID,
+			HashtagID: hashtag.ID,
+			CreatedAt: chirp.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/geophpherie/boot-dev-chirpy-v2/internal/database"
+	"github.com/google/uuid"
+)
+
+const maxInviteUses = 10
+
+type Invite struct {
+	Code      string     `json:"code"`
+	CreatedAt time.Time  `json:"created_at"`
+	MaxUses   int32      `json:"max_uses"`
+	Uses      int32      `json:"uses"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type InviteRedemption struct {
+	Profile
+	RedeemedAt time.Time `json:"redeemed_at"`
+}
+
+func inviteResponse(invite database.Invite) Invite {
+	return Invite{
+		Code:      invite.Code,
+		CreatedAt: invite.CreatedAt,
+		MaxUses:   invite.MaxUses,
+		Uses:      invite.Uses,
+		ExpiresAt: nullTime(invite.ExpiresAt),
+	}
+}
+
+func (cfg *apiConfig) handlerNewInvite(w http.ResponseWriter, r *http.Request) {
+	requestParams := struct {
+		MaxUses   int32      `json:"max_uses"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}{}
+
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&requestParams); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		errorResponse(w, http.StatusBadRequest, "Error decoding request body")
+		return
+	}
+
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	user, err := cfg.dbQueries.GetUser(r.Context(), userId)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if requestParams.MaxUses == 0 {
+		requestParams.MaxUses = 1
+	}
+	if requestParams.MaxUses < 0 || (requestParams.MaxUses > maxInviteUses && !user.IsAdmin) {
+		errorResponse(w, http.StatusBadRequest, "Invalid number of uses")
+		return
+	}
+
+	expiresAt := sql.NullTime{}
+	if requestParams.ExpiresAt != nil {
+		if requestParams.ExpiresAt.Before(time.Now()) {
+			errorResponse(w, http.StatusBadRequest, "Invite would already be expired")
+			return
+		}
+		expiresAt = sql.NullTime{Time: requestParams.ExpiresAt.UTC(), Valid: true}
+	}
+
+	code, err := makeInviteCode()
+	if err != nil {
+		errorResponse(w, http.StatusInternalServerError, "Could not create invite")
+		return
+	}
+
+	args := database.CreateInviteParams{
+		Code:      code,
+		CreatedBy: uuid.NullUUID{UUID: userId, Valid: true},
+		MaxUses:   requestParams.MaxUses,
+		ExpiresAt: expiresAt,
+	}
+	invite, err := cfg.dbQueries.CreateInvite(r.Context(), args)
+	if err != nil {
+		log.Printf("Error creating invite! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not create invite")
+		return
+	}
+
+	jsonResponse(w, http.StatusCreated, inviteResponse(invite))
+}
+
+func (cfg *apiConfig) handlerGetInvites(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	invites, err := cfg.dbQueries.GetInvitesByCreator(r.Context(), uuid.NullUUID{UUID: userId, Valid: true})
+	if err != nil {
+		log.Printf("Error getting invites! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not retrieve invites")
+		return
+	}
+
+	response := []Invite{}
+	for _, invite := range invites {
+		response = append(response, inviteResponse(invite))
+	}
+	jsonResponse(w, http.StatusOK, response)
+}
+
+func (cfg *apiConfig) handlerRevokeInvite(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	args := database.RevokeInviteParams{
+		Code:      r.PathValue("code"),
+		CreatedBy: uuid.NullUUID{UUID: userId, Valid: true},
+	}
+	revoked, err := cfg.dbQueries.RevokeInvite(r.Context(), args)
+	if err != nil {
+		log.Printf("Error revoking invite! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not revoke invite")
+		return
+	}
+	if revoked == 0 {
+		errorResponse(w, http.StatusNotFound, "Invite not found")
+		return
+	}
+
+	jsonResponse(w, http.StatusNoContent, struct{}{})
+}
+
+func (cfg *apiConfig) handlerGetInviteRedemptions(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	invite, err := cfg.dbQueries.GetInvite(r.Context(), r.PathValue("code"))
+	if err != nil {
+		errorResponse(w, http.StatusNotFound, "Invite not found")
+		return
+	}
+
+	if invite.CreatedBy.UUID != userId {
+		if _, err := cfg.authenticateAdmin(r); err != nil {
+			errorResponse(w, http.StatusNotFound, "Invite not found")
+			return
+		}
+	}
+
+	rows, err := cfg.dbQueries.GetInviteRedemptions(r.Context(), invite.Code)
+	if err != nil {
+		log.Printf("Error getting redemptions! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not retrieve redemptions")
+		return
+	}
+
+	response := []InviteRedemption{}
+	for _, row := range rows {
+		response = append(response, InviteRedemption{Profile: profileResponse(row.User), RedeemedAt: row.RedeemedAt})
+	}
+	jsonResponse(w, http.StatusOK, response)
+}
+
+func makeInviteCode() (string, error) {
+	bytes := make([]byte, 8)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/geophpherie/boot-dev-chirpy-v2/internal/database"
+	"github.com/geophpherie/boot-dev-chirpy-v2/internal/pagination"
+	"github.com/google/uuid"
+)
+
+type LikeEntry struct {
+	Profile
+	LikedAt time.Time `json:"liked_at"`
+}
+
+type LikedChirp struct {
+	Chirp
+	LikedAt time.Time `json:"liked_at"`
+}
+
+func (cfg *apiConfig) handlerLike(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chirpId, err := uuid.Parse(r.PathValue("chirpId"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Could not use chirp id")
+		return
+	}
+
+	chirp, err := cfg.dbQueries.GetChirpForViewer(r.Context(), database.GetChirpForViewerParams{
+		ID:       chirpId,
+		ViewerID: uuid.NullUUID{UUID: userId, Valid: true},
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errorResponse(w, http.StatusNotFound, "Chirp not found")
+			return
+		}
+		log.Printf("Error getting chirp! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to like chirp")
+		return
+	}
+	// a rechirp has nothing of its own, the original is what gets liked
+	if chirp.RechirpOf.Valid {
+		errorResponse(w, http.StatusBadRequest, "Rechirps cannot be liked, use the original chirp")
+		return
+	}
+
+	args := database.CreateLikeParams{
+		UserID:  userId,
+		ChirpID: chirpId,
+	}
+	if _, err := cfg.dbQueries.CreateLike(r.Context(), args); err != nil {
+		log.Printf("Error creating like! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to like chirp")
+		return
+	}
+
+	jsonResponse(w, http.StatusNoContent, struct{}{})
+}
+
+func (cfg *apiConfig) handlerUnlike(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chirpId, err := uuid.Parse(r.PathValue("chirpId"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Could not use chirp id")
+		return
+	}
+
+	args := database.DeleteLikeParams{
+		UserID:  userId,
+		ChirpID: chirpId,
+	}
+	if _, err := cfg.dbQueries.DeleteLike(r.Context(), args); err != nil {
+		log.Printf("Error deleting like! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to unlike chirp")
+		return
+	}
+
+	jsonResponse(w, http.StatusNoContent, struct{}{})
+}
+
+func (cfg *apiConfig) handlerGetChirpLikes(w http.ResponseWriter, r *http.Request) {
+	chirpId, err := uuid.Parse(r.PathValue("chirpId"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Could not use chirp id")
+		return
+	}
+
+	viewerId, err := cfg.viewer(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	page, err := pagination.ParseQuery(r.URL.Query())
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	_, err = cfg.dbQueries.GetChirpForViewer(r.Context(), database.GetChirpForViewerParams{
+		ID:       chirpId,
+		ViewerID: viewerId,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errorResponse(w, http.StatusNotFound, "Chirp not found")
+			return
+		}
+		log.Printf("Error getting chirp! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not retrieve likes")
+		return
+	}
+
+	args := database.GetChirpLikersParams{
+		ChirpID:    chirpId,
+		ViewerID:   viewerId,
+		MaxResults: page.Limit + 1,
+	}
+	args.BeforeCreatedAt, args.BeforeID = cursorArgs(page.Cursor)
+
+	rows, err := cfg.dbQueries.GetChirpLikers(r.Context(), args)
+	if err != nil {
+		log.Printf("Error getting likes! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not retrieve likes")
+		return
+	}
+
+	entries := []LikeEntry{}
+	for _, row := range rows {
+		entries = append(entries, LikeEntry{Profile: profileResponse(row.User), LikedAt: row.LikedAt})
+	}
+	entries, nextCursor := paginate(entries, page.Limit, func(entry LikeEntry) pagination.Cursor {
+		return pagination.Cursor{Time: entry.LikedAt, ID: entry.ID}
+	})
+	pageResponse(w, r, entries, nextCursor)
+}
+
+func (cfg *apiConfig) handlerGetUserLikes(w http.ResponseWriter, r *http.Request) {
+	userId, err := uuid.Parse(r.PathValue("userId"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Could not use user id")
+		return
+	}
+
+	viewerId, err := cfg.viewer(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	page, err := pagination.ParseQuery(r.URL.Query())
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	args := database.GetLikedChirpsParams{
+		UserID:     userId,
+		ViewerID:   viewerId,
+		MaxResults: page.Limit + 1,
+	}
+	args.BeforeCreatedAt, args.BeforeID = cursorArgs(page.Cursor)
+
+	rows, err := cfg.dbQueries.GetLikedChirps(r.Context(), args)
+	if err != nil {
+		log.Printf("Error getting liked chirps! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not retrieve likes")
+		return
+	}
+
+	entries := []LikedChirp{}
+	for _, row := range rows {
+		entries = append(entries, LikedChirp{Chirp: chirpResponse(row.Chirp), LikedAt: row.LikedAt})
+	}
+	entries, nextCursor := paginate(entries, page.Limit, func(entry LikedChirp) pagination.Cursor {
+		return pagination.Cursor{Time: entry.LikedAt, ID: entry.Id}
+	})
+
+	chirps := []*Chirp{}
+	for i := range entries {
+		chirps = append(chirps, &entries[i].Chirp)
+	}
+	cfg.hydrateChirps(r.Context(), viewerId, chirps...)
+
+	pageResponse(w, r, entries, nextCursor)
+}
@@ -0,0 +1,498 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"time"
+	"unicode/utf8"
+
+	"github.com/geophpherie/boot-dev-chirpy-v2/internal/database"
+	"github.com/geophpherie/boot-dev-chirpy-v2/internal/media"
+	"github.com/google/uuid"
+)
+
+const (
+	maxImagesPerChirp   = 4
+	maxImageSize        = 5 << 20
+	maxVideoSize        = 15 << 20
+	maxVideoDuration    = 140 * time.Second
+	maxAltTextLength    = 1000
+	uploadMemory        = 1 << 20
+	thumbnailSize       = 400
+	blurhashSize        = 32
+	mediaClaimTimeout   = 10 * time.Minute
+	mediaOrphanLifetime = 24 * time.Hour
+)
+
+var errMediaUnavailable = errors.New("Media not found or not ready")
+var errMediaMixed = errors.New("A video or GIF must be the only attachment")
+var errVideoTooLong = errors.New("video is too long")
+
+// Media describes an upload. Videos never get a thumbnail or blurhash, so
+// HasPreview tells clients up front not to wait for one and to show their
+// own placeholder instead.
+type Media struct {
+	Id           uuid.UUID `json:"id"`
+	CreatedAt    time.Time `json:"created_at"`
+	Type         string    `json:"type"`
+	Status       string    `json:"status"`
+	Url          string    `json:"url,omitempty"`
+	ThumbnailUrl string    `json:"thumbnail_url,omitempty"`
+	AltText      string    `json:"alt_text"`
+	Width        *int32    `json:"width,omitempty"`
+	Height       *int32    `json:"height,omitempty"`
+	DurationMs   *int32    `json:"duration_ms,omitempty"`
+	Blurhash     string    `json:"blurhash,omitempty"`
+	HasPreview   bool      `json:"has_preview"`
+}
+
+func mediaResponse(attachment database.MediaAttachment) Media {
+	response := Media{
+		Id:         attachment.ID,
+		CreatedAt:  attachment.CreatedAt,
+		Type:       attachment.Kind,
+		Status:     attachment.Status,
+		AltText:    attachment.AltText,
+		Width:      nullInt32(attachment.Width),
+		Height:     nullInt32(attachment.Height),
+		DurationMs: nullInt32(attachment.DurationMs),
+		Blurhash:   attachment.Blurhash.String,
+		HasPreview: attachment.Kind != media.KindVideo,
+	}
+	if attachment.Status == "ready" {
+		response.Url = fmt.Sprintf("/api/media/%v/file", attachment.ID)
+		if attachment.ThumbnailPath.Valid {
+			response.ThumbnailUrl = fmt.Sprintf("/api/media/%v/thumbnail", attachment.ID)
+		}
+	}
+	return response
+}
+
+func nullInt32(n sql.NullInt32) *int32 {
+	if !n.Valid {
+		return nil
+	}
+	return &n.Int32
+}
+
+func maxUploadSize(kind string) int64 {
+	if kind == media.KindImage {
+		return maxImageSize
+	}
+	return maxVideoSize
+}
+
+// handlerUploadMedia stores an upload and queues it for processing. It
+// can only be attached to a chirp once the media worker has marked it
+// ready.
+func (cfg *apiConfig) handlerUploadMedia(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxVideoSize+uploadMemory)
+	if err := r.ParseMultipartForm(uploadMemory); err != nil {
+		var maxBytesError *http.MaxBytesError
+		if errors.As(err, &maxBytesError) {
+			errorResponse(w, http.StatusRequestEntityTooLarge, "Upload is too large")
+			return
+		}
+		errorResponse(w, http.StatusBadRequest, "Could not read upload")
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Could not read upload")
+		return
+	}
+	defer file.Close()
+
+	altText := r.FormValue("alt_text")
+	if utf8.RuneCountInString(altText) > maxAltTextLength {
+		errorResponse(w, http.StatusBadRequest, "Alt text is too long")
+		return
+	}
+
+	sniff := make([]byte, 512)
+	n, err := io.ReadFull(file, sniff)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		errorResponse(w, http.StatusBadRequest, "Could not read upload")
+		return
+	}
+	kind, contentType, err := media.Detect(sniff[:n])
+	if err != nil {
+		errorResponse(w, http.StatusUnsupportedMediaType, "Uploads must be JPEG, PNG, GIF or MP4")
+		return
+	}
+	if header.Size > maxUploadSize(kind) {
+		errorResponse(w, http.StatusRequestEntityTooLarge, "Upload is too large")
+		return
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		errorResponse(w, http.StatusBadRequest, "Could not read upload")
+		return
+	}
+
+	path, err := cfg.saveUpload(file)
+	if err != nil {
+		log.Printf("Error saving upload! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not save upload")
+		return
+	}
+
+	args := database.CreateMediaParams{
+		UserID:      userId,
+		Kind:        kind,
+		ContentType: contentType,
+		FilePath:    path,
+		AltText:     altText,
+	}
+	attachment, err := cfg.dbQueries.CreateMedia(r.Context(), args)
+	if err != nil {
+		os.Remove(path)
+		log.Printf("Error creating media! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not save upload")
+		return
+	}
+	notify(cfg.mediaWake)
+
+	jsonResponse(w, http.StatusAccepted, mediaResponse(attachment))
+}
+
+func (cfg *apiConfig) saveUpload(file io.Reader) (path string, err error) {
+	if err := os.MkdirAll(cfg.mediaDir, 0o700); err != nil {
+		return "", err
+	}
+
+	f, err := os.CreateTemp(cfg.mediaDir, "upload-*")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(f.Name())
+		}
+	}()
+
+	_, err = io.Copy(f, file)
+	return f.Name(), err
+}
+
+func (cfg *apiConfig) handlerGetMedia(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	mediaId, err := uuid.Parse(r.PathValue("mediaId"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Could not use media id")
+		return
+	}
+
+	attachment, err := cfg.dbQueries.GetMedia(r.Context(), mediaId)
+	if err != nil || attachment.UserID != userId {
+		errorResponse(w, http.StatusNotFound, "Media not found")
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, mediaResponse(attachment))
+}
+
+func (cfg *apiConfig) handlerUpdateMedia(w http.ResponseWriter, r *http.Request) {
+	requestParams := struct {
+		AltText string `json:"alt_text"`
+	}{}
+
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&requestParams); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		errorResponse(w, http.StatusBadRequest, "Error decoding request body")
+		return
+	}
+
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	mediaId, err := uuid.Parse(r.PathValue("mediaId"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Could not use media id")
+		return
+	}
+
+	if utf8.RuneCountInString(requestParams.AltText) > maxAltTextLength {
+		errorResponse(w, http.StatusBadRequest, "Alt text is too long")
+		return
+	}
+
+	args := database.UpdateMediaAltTextParams{
+		ID:      mediaId,
+		UserID:  userId,
+		AltText: requestParams.AltText,
+	}
+	attachment, err := cfg.dbQueries.UpdateMediaAltText(r.Context(), args)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errorResponse(w, http.StatusNotFound, "Media not found")
+			return
+		}
+		log.Printf("Error updating media! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not update media")
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, mediaResponse(attachment))
+}
+
+func (cfg *apiConfig) handlerGetMediaFile(w http.ResponseWriter, r *http.Request) {
+	cfg.serveMedia(w, r, false)
+}
+
+func (cfg *apiConfig) handlerGetMediaThumbnail(w http.ResponseWriter, r *http.Request) {
+	cfg.serveMedia(w, r, true)
+}
+
+func (cfg *apiConfig) serveMedia(w http.ResponseWriter, r *http.Request, thumbnail bool) {
+	viewerId, err := cfg.viewer(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	mediaId, err := uuid.Parse(r.PathValue("mediaId"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Could not use media id")
+		return
+	}
+
+	attachment, err := cfg.dbQueries.GetMedia(r.Context(), mediaId)
+	if err != nil || attachment.Status != "ready" {
+		errorResponse(w, http.StatusNotFound, "Media not found")
+		return
+	}
+
+	cacheControl, err := cfg.mediaCacheControl(r.Context(), viewerId, attachment)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("Error getting media chirp! %v", err)
+		}
+		errorResponse(w, http.StatusNotFound, "Media not found")
+		return
+	}
+
+	path, contentType := attachment.FilePath, attachment.ContentType
+	if thumbnail {
+		if !attachment.ThumbnailPath.Valid {
+			errorResponse(w, http.StatusNotFound, "Media not found")
+			return
+		}
+		path, contentType = attachment.ThumbnailPath.String, "image/jpeg"
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", cacheControl)
+	http.ServeFile(w, r, path)
+}
+
+// mediaCacheControl decides whether the viewer may see a file and how it can
+// be cached. Uploads follow the chirp they are attached to, so only files on
+// public chirps may be kept by shared caches; the uploader can always see
+// their own.
+func (cfg *apiConfig) mediaCacheControl(ctx context.Context, viewerId uuid.NullUUID, attachment database.MediaAttachment) (string, error) {
+	const private = "private, max-age=31536000, immutable"
+	if viewerId.Valid && viewerId.UUID == attachment.UserID {
+		return private, nil
+	}
+	if !attachment.ChirpID.Valid {
+		return "", sql.ErrNoRows
+	}
+
+	chirp, err := cfg.dbQueries.GetChirpForViewer(ctx, database.GetChirpForViewerParams{
+		ID:       attachment.ChirpID.UUID,
+		ViewerID: viewerId,
+	})
+	if err != nil {
+		return "", err
+	}
+	if chirp.Visibility != "public" && chirp.Visibility != "unlisted" {
+		return private, nil
+	}
+	return "public, max-age=31536000, immutable", nil
+}
+
+// linkMedia attaches a new chirp's uploads in the order they were given.
+// Up to four images can share a chirp, but a video or GIF must be alone.
+func linkMedia(ctx context.Context, qtx *database.Queries, chirp database.Chirp, mediaIds []uuid.UUID) error {
+	args := database.AttachMediaParams{
+		ChirpID: chirp.ID,
+		Ids:     mediaIds,
+		UserID:  chirp.UserID,
+	}
+	kinds, err := qtx.AttachMedia(ctx, args)
+	if err != nil {
+		return err
+	}
+	if len(kinds) != len(mediaIds) {
+		return errMediaUnavailable
+	}
+	if len(kinds) > 1 && slices.ContainsFunc(kinds, func(kind string) bool { return kind != media.KindImage }) {
+		return errMediaMixed
+	}
+	return nil
+}
+
+func validateMediaIds(mediaIds []uuid.UUID) error {
+	if len(mediaIds) > maxImagesPerChirp {
+		return fmt.Errorf("A chirp can have at most %v attachments", maxImagesPerChirp)
+	}
+	for i, id := range mediaIds {
+		if slices.Contains(mediaIds[:i], id) {
+			return errors.New("Media can only be attached once")
+		}
+	}
+	return nil
+}
+
+func (cfg *apiConfig) attachChirpMedia(ctx context.Context, chirps []*Chirp) error {
+	ids := []uuid.UUID{}
+	for _, chirp := range chirps {
+		if !chirp.Deleted && !chirp.Unavailable {
+			ids = append(ids, chirp.Id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	rows, err := cfg.dbQueries.GetChirpMedia(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	attachments := map[uuid.UUID][]Media{}
+	for _, row := range rows {
+		attachments[row.ChirpID.UUID] = append(attachments[row.ChirpID.UUID], mediaResponse(row))
+	}
+	for _, chirp := range chirps {
+		chirp.Media = attachments[chirp.Id]
+	}
+	return nil
+}
+
+// processMedia garbage-collects uploads that were never attached or whose
+// chirp is gone, then works through the processing queue.
+func (cfg *apiConfig) processMedia(ctx context.Context) error {
+	orphaned, err := cfg.dbQueries.DeleteOrphanedMedia(ctx, time.Now().Add(-mediaOrphanLifetime))
+	if err != nil {
+		return err
+	}
+	for _, row := range orphaned {
+		os.Remove(row.FilePath)
+		if row.ThumbnailPath.Valid {
+			os.Remove(row.ThumbnailPath.String)
+		}
+	}
+
+	for {
+		attachment, err := cfg.dbQueries.ClaimMedia(ctx, mediaClaimTimeout.Seconds())
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		args, err := processUpload(attachment)
+		if err != nil {
+			log.Printf("Error processing media %v :: %v", attachment.ID, err)
+			if err := cfg.dbQueries.FailMedia(ctx, attachment.ID); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := cfg.dbQueries.CompleteMedia(ctx, args); err != nil {
+			return err
+		}
+	}
+}
+
+// processUpload extracts an upload's metadata. Images and GIFs also get a
+// JPEG thumbnail and a blurhash; videos only get their size and duration
+// since decoding frames is beyond the standard library.
+func processUpload(attachment database.MediaAttachment) (database.CompleteMediaParams, error) {
+	f, err := os.Open(attachment.FilePath)
+	if err != nil {
+		return database.CompleteMediaParams{}, err
+	}
+	defer f.Close()
+
+	args := database.CompleteMediaParams{ID: attachment.ID}
+
+	if attachment.Kind == media.KindVideo {
+		info, err := media.ProbeVideo(f)
+		if err != nil {
+			return database.CompleteMediaParams{}, err
+		}
+		if info.Duration > maxVideoDuration {
+			return database.CompleteMediaParams{}, errVideoTooLong
+		}
+		args.Width = sql.NullInt32{Int32: int32(info.Width), Valid: true}
+		args.Height = sql.NullInt32{Int32: int32(info.Height), Valid: true}
+		args.DurationMs = sql.NullInt32{Int32: int32(info.Duration.Milliseconds()), Valid: true}
+		return args, nil
+	}
+
+	img, err := media.DecodeImage(f)
+	if err != nil {
+		return database.CompleteMediaParams{}, err
+	}
+
+	thumbnailPath := attachment.FilePath + "-thumbnail.jpg"
+	if err := writeThumbnail(thumbnailPath, media.Thumbnail(img, thumbnailSize)); err != nil {
+		return database.CompleteMediaParams{}, err
+	}
+
+	args.Width = sql.NullInt32{Int32: int32(img.Bounds().Dx()), Valid: true}
+	args.Height = sql.NullInt32{Int32: int32(img.Bounds().Dy()), Valid: true}
+	args.Blurhash = sql.NullString{String: media.Blurhash(media.Thumbnail(img, blurhashSize), 4, 3), Valid: true}
+	args.ThumbnailPath = sql.NullString{String: thumbnailPath, Valid: true}
+	return args, nil
+}
+
+func writeThumbnail(path string, img image.Image) (err error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(path)
+		}
+	}()
+
+	return jpeg.Encode(f, img, &jpeg.Options{Quality: 80})
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+
+	"github.com/geophpherie/boot-dev-chirpy-v2/internal/database"
+	"github.com/geophpherie/boot-dev-chirpy-v2/internal/entities"
+	"github.com/google/uuid"
+)
+
+type MentionEntity struct {
+	UserId uuid.UUID `json:"user_id"`
+	Handle string    `json:"handle"`
+	Start  int32     `json:"start"`
+	End    int32     `json:"end"`
+}
+
+// saveMentions replaces the mentions recorded for a chirp with the ones in
+// its current body and notifies anyone newly mentioned. Handles that don't
+// resolve, or belong to someone on either side of a block, stay plain text.
+func saveMentions(ctx context.Context, qtx *database.Queries, chirp database.Chirp) error {
+	if err := qtx.DeleteChirpMentions(ctx, chirp.ID); err != nil {
+		return err
+	}
+
+	mentions := entities.Mentions(chirp.Body)
+	if len(mentions) == 0 {
+		return nil
+	}
+
+	handles := []string{}
+	for _, mention := range mentions {
+		handles = append(handles, mention.Handle)
+	}
+	users, err := qtx.ResolveMentionHandles(ctx, database.ResolveMentionHandlesParams{
+		Handles:  handles,
+		AuthorID: chirp.UserID,
+	})
+	if err != nil {
+		return err
+	}
+
+	userIds := map[string]uuid.UUID{}
+	for _, user := range users {
+		userIds[user.Handle.String] = user.ID
+	}
+
+	for _, mention := range mentions {
+		userId, ok := userIds[mention.Handle]
+		if !ok {
+			continue
+		}
+
+		err := qtx.AddChirpMention(ctx, database.AddChirpMentionParams{
+			ChirpID:     chirp.ID,
+			UserID:      userId,
+			StartOffset: int32(mention.Start),
+			EndOffset:   int32(mention.End),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return qtx.CreateMentionNotifications(ctx, chirp.ID)
+}
+
+func (cfg *apiConfig) attachMentions(ctx context.Context, chirps []*Chirp) error {
+	ids := []uuid.UUID{}
+	for _, chirp := range chirps {
+		if !chirp.Deleted && !chirp.Unavailable {
+			ids = append(ids, chirp.Id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	rows, err := cfg.dbQueries.GetChirpMentions(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	mentions := map[uuid.UUID][]MentionEntity{}
+	for _, row := range rows {
+		mentions[row.ChirpID] = append(mentions[row.ChirpID], MentionEntity{
+			UserId: row.UserID,
+			Handle: row.Handle.String,
+			Start:  row.StartOffset,
+			End:    row.EndOffset,
+		})
+	}
+	for _, chirp := range chirps {
+		chirp.Mentions = mentions[chirp.Id]
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/geophpherie/boot-dev-chirpy-v2/internal/database"
+	"github.com/geophpherie/boot-dev-chirpy-v2/internal/pagination"
+	"github.com/google/uuid"
+)
+
+type Notification struct {
+	Id        uuid.UUID  `json:"id"`
+	Type      string     `json:"type"`
+	CreatedAt time.Time  `json:"created_at"`
+	Read      bool       `json:"read"`
+	Actor     Profile    `json:"actor"`
+	ChirpId   *uuid.UUID `json:"chirp_id,omitempty"`
+}
+
+func notificationResponse(row database.GetNotificationsRow) Notification {
+	notification := Notification{
+		Id:        row.Notification.ID,
+		Type:      row.Notification.Type,
+		CreatedAt: row.Notification.CreatedAt,
+		Read:      row.Notification.ReadAt.Valid,
+		Actor:     profileResponse(row.User),
+	}
+	if row.Notification.ChirpID.Valid {
+		notification.ChirpId = &row.Notification.ChirpID.UUID
+	}
+	return notification
+}
+
+func (cfg *apiConfig) handlerGetNotifications(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	page, err := pagination.ParseQuery(r.URL.Query())
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	args := database.GetNotificationsParams{
+		UserID:     userId,
+		MaxResults: page.Limit + 1,
+	}
+	args.BeforeCreatedAt, args.BeforeID = cursorArgs(page.Cursor)
+
+	rows, err := cfg.dbQueries.GetNotifications(r.Context(), args)
+	if err != nil {
+		log.Printf("Error getting notifications! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not retrieve notifications")
+		return
+	}
+
+	notifications := []Notification{}
+	for _, row := range rows {
+		notifications = append(notifications, notificationResponse(row))
+	}
+	notifications, nextCursor := paginate(notifications, page.Limit, func(notification Notification) pagination.Cursor {
+		return pagination.Cursor{Time: notification.CreatedAt, ID: notification.Id}
+	})
+	pageResponse(w, r, notifications, nextCursor)
+}
+
+func (cfg *apiConfig) handlerGetUnreadNotificationCount(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	count, err := cfg.dbQueries.CountUnreadNotifications(r.Context(), userId)
+	if err != nil {
+		log.Printf("Error counting notifications! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not retrieve notifications")
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, struct {
+		Unread int64 `json:"unread"`
+	}{
+		Unread: count,
+	})
+}
+
+func (cfg *apiConfig) handlerMarkNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if err := cfg.dbQueries.MarkNotificationsRead(r.Context(), userId); err != nil {
+		log.Printf("Error marking notifications read! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to update notifications")
+		return
+	}
+
+	jsonResponse(w, http.StatusNoContent, struct{}{})
+}
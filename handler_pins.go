@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/geophpherie/boot-dev-chirpy-v2/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	maxPinnedChirps    = 3
+	maxRedPinnedChirps = 6
+)
+
+func pinLimit(user database.User) int {
+	if user.IsChirpyRed {
+		return maxRedPinnedChirps
+	}
+	return maxPinnedChirps
+}
+
+func (cfg *apiConfig) handlerPinChirp(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chirpId, err := uuid.Parse(r.PathValue("chirpId"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Could not use chirp id")
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		log.Printf("Error starting transaction! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to pin chirp")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	// locking the user makes concurrent pins wait, so they all see the
+	// same count against the limit
+	user, err := qtx.GetUserForUpdate(r.Context(), userId)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chirp, err := qtx.GetChirpForViewer(r.Context(), database.GetChirpForViewerParams{
+		ID:       chirpId,
+		ViewerID: uuid.NullUUID{UUID: userId, Valid: true},
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errorResponse(w, http.StatusNotFound, "Chirp not found")
+			return
+		}
+		log.Printf("Error getting chirp! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to pin chirp")
+		return
+	}
+	if chirp.UserID != userId {
+		errorResponse(w, http.StatusForbidden, "Only your own chirps can be pinned")
+		return
+	}
+	if chirp.RechirpOf.Valid {
+		errorResponse(w, http.StatusBadRequest, "Rechirps cannot be pinned")
+		return
+	}
+
+	pinned, err := qtx.GetPinnedChirpIds(r.Context(), userId)
+	if err != nil {
+		log.Printf("Error getting pinned chirps! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to pin chirp")
+		return
+	}
+	for _, id := range pinned {
+		if id == chirpId {
+			jsonResponse(w, http.StatusNoContent, struct{}{})
+			return
+		}
+	}
+
+	if limit := pinLimit(user); len(pinned) >= limit {
+		errorResponse(w, http.StatusBadRequest, fmt.Sprintf("At most %v chirps can be pinned", limit))
+		return
+	}
+
+	if err := qtx.PinChirp(r.Context(), database.PinChirpParams{
+		UserID:  userId,
+		ChirpID: chirpId,
+	}); err != nil {
+		log.Printf("Error pinning chirp! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to pin chirp")
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error pinning chirp! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to pin chirp")
+		return
+	}
+
+	jsonResponse(w, http.StatusNoContent, struct{}{})
+}
+
+func (cfg *apiConfig) handlerUnpinChirp(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chirpId, err := uuid.Parse(r.PathValue("chirpId"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Could not use chirp id")
+		return
+	}
+
+	if _, err := cfg.dbQueries.UnpinChirp(r.Context(), database.UnpinChirpParams{
+		UserID:  userId,
+		ChirpID: chirpId,
+	}); err != nil {
+		log.Printf("Error unpinning chirp! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to unpin chirp")
+		return
+	}
+
+	jsonResponse(w, http.StatusNoContent, struct{}{})
+}
+
+// handlerReorderPins sets the order pinned chirps are shown in. The request
+// must list exactly the chirps that are currently pinned.
+func (cfg *apiConfig) handlerReorderPins(w http.ResponseWriter, r *http.Request) {
+	requestParams := struct {
+		ChirpIds []uuid.UUID `json:"chirp_ids"`
+	}{}
+
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&requestParams); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		errorResponse(w, http.StatusBadRequest, "Error decoding request body")
+		return
+	}
+
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	tx, err := cfg.db.BeginTx(r.Context(), nil)
+	if err != nil {
+		log.Printf("Error starting transaction! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to reorder pinned chirps")
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	pinned, err := qtx.GetPinnedChirpIds(r.Context(), userId)
+	if err != nil {
+		log.Printf("Error getting pinned chirps! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to reorder pinned chirps")
+		return
+	}
+
+	if !samePins(pinned, requestParams.ChirpIds) {
+		errorResponse(w, http.StatusBadRequest, "chirp_ids must list each pinned chirp exactly once")
+		return
+	}
+
+	if err := qtx.ReorderPinnedChirps(r.Context(), database.ReorderPinnedChirpsParams{
+		ChirpIds: requestParams.ChirpIds,
+		UserID:   userId,
+	}); err != nil {
+		log.Printf("Error reordering pinned chirps! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to reorder pinned chirps")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error committing pin order! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to reorder pinned chirps")
+		return
+	}
+
+	chirps, err := cfg.pinnedChirps(r.Context(), userId, uuid.NullUUID{UUID: userId, Valid: true})
+	if err != nil {
+		log.Printf("Error getting pinned chirps! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to reorder pinned chirps")
+		return
+	}
+	jsonResponse(w, http.StatusOK, chirps)
+}
+
+func samePins(pinned, ids []uuid.UUID) bool {
+	if len(pinned) != len(ids) {
+		return false
+	}
+	seen := map[uuid.UUID]bool{}
+	for _, id := range pinned {
+		seen[id] = true
+	}
+	for _, id := range ids {
+		if !seen[id] {
+			return false
+		}
+		delete(seen, id)
+	}
+	return true
+}
+
+// pinnedChirps returns a user's pinned chirps in pin order, ready to be
+// shown to the viewer.
+func (cfg *apiConfig) pinnedChirps(ctx context.Context, userId uuid.UUID, viewerId uuid.NullUUID) ([]Chirp, error) {
+	rows, err := cfg.dbQueries.GetPinnedChirps(ctx, database.GetPinnedChirpsParams{
+		UserID:   userId,
+		ViewerID: viewerId,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := []Chirp{}
+	for _, row := range rows {
+		chirp := chirpResponse(row.Chirp)
+		chirp.Pinned = true
+		response = append(response, chirp)
+	}
+	cfg.hydrateChirps(ctx, viewerId, pointers(response)...)
+	return response, nil
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/geophpherie/boot-dev-chirpy-v2/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	minPollOptions      = 2
+	maxPollOptions      = 4
+	maxPollOptionLength = 25
+	minPollDuration     = 5 * time.Minute
+	maxPollDuration     = 7 * 24 * time.Hour
+)
+
+// pollInput is the poll a client may attach to a new chirp.
+type pollInput struct {
+	Options        []string  `json:"options"`
+	ClosesAt       time.Time `json:"closes_at"`
+	MultipleChoice bool      `json:"multiple_choice"`
+}
+
+type Poll struct {
+	Options        []PollOption `json:"options"`
+	MultipleChoice bool         `json:"multiple_choice"`
+	ClosesAt       time.Time    `json:"closes_at"`
+	Closed         bool         `json:"closed"`
+	VoterCount     *int32       `json:"voter_count,omitempty"`
+	MyChoices      []int32      `json:"my_choices,omitempty"`
+}
+
+// PollOption leaves out its vote count while the results are hidden from
+// the viewer.
+type PollOption struct {
+	Text  string `json:"text"`
+	Votes *int32 `json:"votes,omitempty"`
+}
+
+func validatePoll(poll *pollInput) error {
+	if len(poll.Options) < minPollOptions || len(poll.Options) > maxPollOptions {
+		return fmt.Errorf("A poll needs between %v and %v options", minPollOptions, maxPollOptions)
+	}
+
+	for i, option := range poll.Options {
+		option = strings.TrimSpace(option)
+		if option == "" {
+			return errors.New("Poll options cannot be empty")
+		}
+		if utf8.RuneCountInString(option) > maxPollOptionLength {
+			return fmt.Errorf("Poll options can be at most %v characters", maxPollOptionLength)
+		}
+		if slices.Contains(poll.Options[:i], option) {
+			return errors.New("Poll options must be different")
+		}
+		poll.Options[i] = option
+	}
+
+	duration := time.Until(poll.ClosesAt)
+	if duration < minPollDuration {
+		return fmt.Errorf("closes_at must be at least %v away", minPollDuration)
+	}
+	if duration > maxPollDuration {
+		return errors.New("closes_at is too far in the future")
+	}
+	return nil
+}
+
+func createPoll(ctx context.Context, qtx *database.Queries, chirpId uuid.UUID, poll *pollInput) error {
+	err := qtx.CreatePoll(ctx, database.CreatePollParams{
+		ChirpID:        chirpId,
+		MultipleChoice: poll.MultipleChoice,
+		ClosesAt:       poll.ClosesAt.UTC(),
+	})
+	if err != nil {
+		return err
+	}
+
+	for i, option := range poll.Options {
+		err := qtx.CreatePollOption(ctx, database.CreatePollOptionParams{
+			ChirpID:  chirpId,
+			Position: int32(i),
+			Body:     option,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attachPolls fills in the poll on any chirp that has one. Vote counts are
+// only shown once the viewer has voted or the poll has closed.
+func (cfg *apiConfig) attachPolls(ctx context.Context, viewerId uuid.NullUUID, chirps []*Chirp) error {
+	ids := []uuid.UUID{}
+	for _, chirp := range chirps {
+		if !chirp.Deleted && !chirp.Unavailable {
+			ids = append(ids, chirp.Id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	polls, err := cfg.dbQueries.GetPolls(ctx, ids)
+	if err != nil {
+		return err
+	}
+	if len(polls) == 0 {
+		return nil
+	}
+
+	pollIds := []uuid.UUID{}
+	for _, poll := range polls {
+		pollIds = append(pollIds, poll.ChirpID)
+	}
+
+	options, err := cfg.dbQueries.GetPollOptions(ctx, pollIds)
+	if err != nil {
+		return err
+	}
+
+	votes := []database.PollVote{}
+	if viewerId.Valid {
+		votes, err = cfg.dbQueries.GetPollVotes(ctx, database.GetPollVotesParams{
+			UserID:   viewerId.UUID,
+			ChirpIds: pollIds,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	responses := map[uuid.UUID]*Poll{}
+	for _, poll := range polls {
+		responses[poll.ChirpID] = pollResponse(poll, options, votes)
+	}
+	for _, chirp := range chirps {
+		chirp.Poll = responses[chirp.Id]
+	}
+	return nil
+}
+
+func pollResponse(poll database.Poll, options []database.PollOption, votes []database.PollVote) *Poll {
+	response := &Poll{
+		Options:        []PollOption{},
+		MultipleChoice: poll.MultipleChoice,
+		ClosesAt:       poll.ClosesAt,
+		Closed:         !poll.ClosesAt.After(time.Now()),
+	}
+
+	for _, vote := range votes {
+		if vote.ChirpID == poll.ChirpID {
+			response.MyChoices = vote.Choices
+		}
+	}
+
+	showResults := response.Closed || response.MyChoices != nil
+	if showResults {
+		response.VoterCount = &poll.VoterCount
+	}
+	for _, option := range options {
+		if option.ChirpID != poll.ChirpID {
+			continue
+		}
+		pollOption := PollOption{Text: option.Body}
+		if showResults {
+			pollOption.Votes = &option.VoteCount
+		}
+		response.Options = append(response.Options, pollOption)
+	}
+	return response
+}
+
+func (cfg *apiConfig) handlerVoteInPoll(w http.ResponseWriter, r *http.Request) {
+	requestParams := struct {
+		Choices []int32 `json:"choices"`
+	}{}
+
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&requestParams); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		errorResponse(w, http.StatusBadRequest, "Error decoding request body")
+		return
+	}
+
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	viewerId := uuid.NullUUID{UUID: userId, Valid: true}
+
+	chirpId, err := uuid.Parse(r.PathValue("chirpId"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Could not use chirp id")
+		return
+	}
+
+	chirp, err := cfg.dbQueries.GetChirpForViewer(r.Context(), database.GetChirpForViewerParams{
+		ID:       chirpId,
+		ViewerID: viewerId,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errorResponse(w, http.StatusNotFound, "Chirp not found")
+			return
+		}
+		log.Printf("Error getting chirp! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to vote")
+		return
+	}
+
+	poll, err := cfg.dbQueries.GetPoll(r.Context(), chirp.ID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errorResponse(w, http.StatusNotFound, "Chirp has no poll")
+			return
+		}
+		log.Printf("Error getting poll! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to vote")
+		return
+	}
+
+	options, err := cfg.dbQueries.GetPollOptions(r.Context(), []uuid.UUID{poll.ChirpID})
+	if err != nil {
+		log.Printf("Error getting poll options! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to vote")
+		return
+	}
+
+	if msg := validateChoices(requestParams.Choices, len(options), poll.MultipleChoice); msg != "" {
+		errorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	voted, err := cfg.dbQueries.CreatePollVote(r.Context(), database.CreatePollVoteParams{
+		UserID:  userId,
+		Choices: requestParams.Choices,
+		ChirpID: poll.ChirpID,
+	})
+	if err != nil {
+		log.Printf("Error voting in poll! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to vote")
+		return
+	}
+	if voted == 0 {
+		if !poll.ClosesAt.After(time.Now()) {
+			errorResponse(w, http.StatusBadRequest, "Poll has closed")
+			return
+		}
+		errorResponse(w, http.StatusConflict, "You have already voted in this poll")
+		return
+	}
+
+	response := chirpResponse(chirp)
+	if err := cfg.attachPolls(r.Context(), viewerId, []*Chirp{&response}); err != nil {
+		log.Printf("Error getting poll! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to vote")
+		return
+	}
+	jsonResponse(w, http.StatusCreated, response.Poll)
+}
+
+func validateChoices(choices []int32, optionCount int, multipleChoice bool) string {
+	if len(choices) == 0 {
+		return "choices is required"
+	}
+	if !multipleChoice && len(choices) > 1 {
+		return "This poll only allows one choice"
+	}
+	for i, choice := range choices {
+		if choice < 0 || int(choice) >= optionCount {
+			return fmt.Sprintf("invalid choice %v", choice)
+		}
+		if slices.Contains(choices[:i], choice) {
+			return "choices must be different"
+		}
+	}
+	return ""
+}
+
+// notifyClosedPolls tells authors their polls have closed. Each poll is
+// claimed with SKIP LOCKED so concurrent workers notify it only once.
+func (cfg *apiConfig) notifyClosedPolls(ctx context.Context) error {
+	for {
+		notified, err := cfg.notifyNextClosedPoll(ctx)
+		if err != nil {
+			return err
+		}
+		if !notified {
+			return nil
+		}
+	}
+}
+
+func (cfg *apiConfig) notifyNextClosedPoll(ctx context.Context) (bool, error) {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	poll, err := qtx.ClaimClosedPoll(ctx)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if err := qtx.CreatePollClosedNotification(ctx, poll.ChirpID); err != nil {
+		return false, err
+	}
+	if err := qtx.MarkPollNotified(ctx, poll.ChirpID); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
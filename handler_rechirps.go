@@ -0,0 +1,92 @@
+package main
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+
+	"github.com/geophpherie/boot-dev-chirpy-v2/internal/database"
+	"github.com/google/uuid"
+)
+
+func (cfg *apiConfig) handlerRechirp(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chirpId, err := uuid.Parse(r.PathValue("chirpId"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Could not use chirp id")
+		return
+	}
+
+	viewerId := uuid.NullUUID{UUID: userId, Valid: true}
+	original, err := cfg.getOriginalChirpForViewer(r.Context(), chirpId, viewerId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errorResponse(w, http.StatusNotFound, "Chirp not found")
+			return
+		}
+		log.Printf("Error getting chirp! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to rechirp")
+		return
+	}
+	if original.Visibility != "public" && original.Visibility != "unlisted" {
+		errorResponse(w, http.StatusForbidden, "Only public chirps can be rechirped")
+		return
+	}
+	originalId := original.ID
+
+	code := http.StatusCreated
+	rechirp, err := cfg.dbQueries.CreateRechirp(r.Context(), database.CreateRechirpParams{
+		UserID:    userId,
+		RechirpOf: originalId,
+	})
+	if err == sql.ErrNoRows {
+		// already rechirped, so just report it
+		code = http.StatusOK
+		rechirp, err = cfg.dbQueries.GetRechirp(r.Context(), database.GetRechirpParams{
+			UserID:    userId,
+			RechirpOf: uuid.NullUUID{UUID: originalId, Valid: true},
+		})
+	} else if err == nil {
+		cfg.publishChirp(r.Context(), rechirp)
+	}
+	if err != nil {
+		log.Printf("Error creating rechirp! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to rechirp")
+		return
+	}
+
+	response := chirpResponse(rechirp)
+	cfg.hydrateChirps(r.Context(), viewerId, &response)
+	jsonResponse(w, code, response)
+}
+
+func (cfg *apiConfig) handlerUndoRechirp(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chirpId, err := uuid.Parse(r.PathValue("chirpId"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Could not use chirp id")
+		return
+	}
+
+	args := database.DeleteRechirpParams{
+		UserID:    userId,
+		RechirpOf: uuid.NullUUID{UUID: chirpId, Valid: true},
+	}
+	if _, err := cfg.dbQueries.DeleteRechirp(r.Context(), args); err != nil {
+		log.Printf("Error deleting rechirp! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to undo rechirp")
+		return
+	}
+
+	jsonResponse(w, http.StatusNoContent, struct{}{})
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/geophpherie/boot-dev-chirpy-v2/internal/database"
+	"github.com/geophpherie/boot-dev-chirpy-v2/internal/pagination"
+	"github.com/google/uuid"
+)
+
+const (
+	registrationOpen     = "open"
+	registrationInvite   = "invite"
+	registrationApproval = "approval"
+)
+
+var errInvalidInvite = errors.New("invite code is invalid, expired or used up")
+
+func parseRegistrationMode(mode string) (string, error) {
+	switch mode {
+	case "":
+		return registrationOpen, nil
+	case registrationOpen, registrationInvite, registrationApproval:
+		return mode, nil
+	}
+	return "", errors.New("unknown registration mode " + mode)
+}
+
+// createInvitedUser redeems an invite and creates the user in a single
+// transaction so a failed signup never uses up an invite.
+func (cfg *apiConfig) createInvitedUser(ctx context.Context, params database.CreateUserParams, code string) (database.User, error) {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return database.User{}, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	invite, err := qtx.RedeemInvite(ctx, code)
+	if err == sql.ErrNoRows {
+		return database.User{}, errInvalidInvite
+	}
+	if err != nil {
+		return database.User{}, err
+	}
+
+	user, err := qtx.CreateUser(ctx, params)
+	if err != nil {
+		return database.User{}, err
+	}
+
+	args := database.CreateInviteRedemptionParams{
+		InviteCode: invite.Code,
+		UserID:     user.ID,
+	}
+	if err := qtx.CreateInviteRedemption(ctx, args); err != nil {
+		return database.User{}, err
+	}
+
+	return user, tx.Commit()
+}
+
+func (cfg *apiConfig) handlerGetPendingRegistrations(w http.ResponseWriter, r *http.Request) {
+	if _, err := cfg.authenticateAdmin(r); err != nil {
+		errorResponse(w, http.StatusForbidden, "forbidden")
+		return
+	}
+
+	page, err := pagination.ParseQuery(r.URL.Query())
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	args := database.GetPendingUsersParams{
+		MaxResults: page.Limit + 1,
+	}
+	args.AfterCreatedAt, args.AfterID = cursorArgs(page.Cursor)
+
+	users, err := cfg.dbQueries.GetPendingUsers(r.Context(), args)
+	if err != nil {
+		log.Printf("Error getting pending users! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not retrieve registrations")
+		return
+	}
+
+	users, nextCursor := paginate(users, page.Limit, func(user database.User) pagination.Cursor {
+		return pagination.Cursor{Time: user.CreatedAt, ID: user.ID}
+	})
+
+	response := []User{}
+	for _, user := range users {
+		response = append(response, userResponse(user))
+	}
+	pageResponse(w, r, response, nextCursor)
+}
+
+func (cfg *apiConfig) handlerApproveRegistration(w http.ResponseWriter, r *http.Request) {
+	cfg.setRegistrationStatus(w, r, "active")
+}
+
+func (cfg *apiConfig) handlerRejectRegistration(w http.ResponseWriter, r *http.Request) {
+	cfg.setRegistrationStatus(w, r, "rejected")
+}
+
+func (cfg *apiConfig) setRegistrationStatus(w http.ResponseWriter, r *http.Request, status string) {
+	if _, err := cfg.authenticateAdmin(r); err != nil {
+		errorResponse(w, http.StatusForbidden, "forbidden")
+		return
+	}
+
+	userId, err := uuid.Parse(r.PathValue("userId"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Could not use user id")
+		return
+	}
+
+	args := database.SetPendingUserStatusParams{
+		ID:     userId,
+		Status: status,
+	}
+	user, err := cfg.dbQueries.SetPendingUserStatus(r.Context(), args)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errorResponse(w, http.StatusNotFound, "Pending registration not found")
+			return
+		}
+		log.Printf("Error updating registration! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to update registration")
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, userResponse(user))
+}
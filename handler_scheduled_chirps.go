@@ -0,0 +1,360 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/geophpherie/boot-dev-chirpy-v2/internal/database"
+	"github.com/google/uuid"
+)
+
+const maxScheduleAhead = 365 * 24 * time.Hour
+
+type ScheduledChirp struct {
+	Id             uuid.UUID   `json:"id"`
+	CreatedAt      time.Time   `json:"created_at"`
+	UpdatedAt      time.Time   `json:"updated_at"`
+	Body           string      `json:"body"`
+	PublishAt      time.Time   `json:"publish_at"`
+	InReplyTo      *uuid.UUID  `json:"in_reply_to,omitempty"`
+	QuoteOfId      *uuid.UUID  `json:"quote_of_id,omitempty"`
+	MediaIds       []uuid.UUID `json:"media_ids"`
+	Visibility     string      `json:"visibility"`
+	ContentWarning string      `json:"content_warning,omitempty"`
+	Sensitive      bool        `json:"sensitive"`
+	Status         string      `json:"status"`
+	Failure        string      `json:"failure,omitempty"`
+}
+
+func scheduledChirpResponse(scheduled database.ScheduledChirp) ScheduledChirp {
+	response := ScheduledChirp{
+		Id:             scheduled.ID,
+		CreatedAt:      scheduled.CreatedAt,
+		UpdatedAt:      scheduled.UpdatedAt,
+		Body:           scheduled.Body,
+		PublishAt:      scheduled.PublishAt,
+		MediaIds:       scheduled.MediaIds,
+		Visibility:     scheduled.Visibility,
+		ContentWarning: scheduled.ContentWarning.String,
+		Sensitive:      scheduled.Sensitive,
+		Status:         scheduled.Status,
+		Failure:        scheduled.Failure.String,
+	}
+	if response.MediaIds == nil {
+		response.MediaIds = []uuid.UUID{}
+	}
+	if scheduled.InReplyTo.Valid {
+		response.InReplyTo = &scheduled.InReplyTo.UUID
+	}
+	if scheduled.QuoteOf.Valid {
+		response.QuoteOfId = &scheduled.QuoteOf.UUID
+	}
+	return response
+}
+
+func validatePublishAt(publishAt time.Time) string {
+	now := time.Now()
+	if !publishAt.After(now) {
+		return "publish_at must be in the future"
+	}
+	if publishAt.After(now.Add(maxScheduleAhead)) {
+		return "publish_at is too far in the future"
+	}
+	return ""
+}
+
+// scheduleChirp stores an already validated chirp for the scheduled chirp
+// worker to publish later. Its media are held back from garbage
+// collection until then.
+func (cfg *apiConfig) scheduleChirp(w http.ResponseWriter, r *http.Request, args database.CreateChirpParams, mediaIds []uuid.UUID, publishAt time.Time) {
+	if msg := validatePublishAt(publishAt); msg != "" {
+		errorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	// media_ids can't be NULL, and pq sends a nil slice as NULL
+	if mediaIds == nil {
+		mediaIds = []uuid.UUID{}
+	}
+
+	if len(mediaIds) > 0 {
+		count, err := cfg.dbQueries.CountSchedulableMedia(r.Context(), database.CountSchedulableMediaParams{
+			Ids:    mediaIds,
+			UserID: args.UserID,
+		})
+		if err != nil {
+			log.Printf("Error checking media! %v", err)
+			errorResponse(w, http.StatusInternalServerError, "Could not schedule chirp")
+			return
+		}
+		if count != int64(len(mediaIds)) {
+			errorResponse(w, http.StatusBadRequest, errMediaUnavailable.Error())
+			return
+		}
+	}
+
+	scheduled, err := cfg.dbQueries.CreateScheduledChirp(r.Context(), database.CreateScheduledChirpParams{
+		UserID:         args.UserID,
+		Body:           args.Body,
+		InReplyTo:      args.InReplyTo,
+		ConversationID: args.ConversationID,
+		QuoteOf:        args.QuoteOf,
+		MediaIds:       mediaIds,
+		PublishAt:      publishAt.UTC(),
+		Visibility:     args.Visibility,
+		ContentWarning: args.ContentWarning,
+		Sensitive:      args.Sensitive,
+	})
+	if err != nil {
+		log.Printf("Error scheduling chirp! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not schedule chirp")
+		return
+	}
+
+	jsonResponse(w, http.StatusCreated, scheduledChirpResponse(scheduled))
+}
+
+func (cfg *apiConfig) handlerGetScheduledChirps(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	scheduled, err := cfg.dbQueries.GetScheduledChirps(r.Context(), userId)
+	if err != nil {
+		log.Printf("Error getting scheduled chirps! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not retrieve scheduled chirps")
+		return
+	}
+
+	response := []ScheduledChirp{}
+	for _, chirp := range scheduled {
+		response = append(response, scheduledChirpResponse(chirp))
+	}
+	jsonResponse(w, http.StatusOK, response)
+}
+
+// handlerEditScheduledChirp changes the body or publish time of a chirp
+// that hasn't gone out yet. Editing a failed chirp schedules it again.
+func (cfg *apiConfig) handlerEditScheduledChirp(w http.ResponseWriter, r *http.Request) {
+	requestParams := struct {
+		Body      *string    `json:"body"`
+		PublishAt *time.Time `json:"publish_at"`
+	}{}
+
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&requestParams); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		errorResponse(w, http.StatusBadRequest, "Error decoding request body")
+		return
+	}
+
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	scheduledId, err := uuid.Parse(r.PathValue("scheduledId"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Could not use scheduled chirp id")
+		return
+	}
+
+	scheduled, err := cfg.dbQueries.GetScheduledChirp(r.Context(), database.GetScheduledChirpParams{
+		ID:     scheduledId,
+		UserID: userId,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errorResponse(w, http.StatusNotFound, "Scheduled chirp not found")
+			return
+		}
+		log.Printf("Error getting scheduled chirp! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not edit scheduled chirp")
+		return
+	}
+
+	args := database.UpdateScheduledChirpParams{
+		ID:        scheduled.ID,
+		UserID:    userId,
+		Body:      scheduled.Body,
+		PublishAt: scheduled.PublishAt,
+	}
+
+	if requestParams.Body != nil {
+		user, err := cfg.dbQueries.GetUser(r.Context(), userId)
+		if err != nil {
+			errorResponse(w, http.StatusUnauthorized, "unauthorized")
+			return
+		}
+		args.Body, err = validateChirpBody(*requestParams.Body, cfg.chirpRules(user))
+		if err != nil {
+			errorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	if requestParams.PublishAt != nil {
+		args.PublishAt = requestParams.PublishAt.UTC()
+	}
+	if msg := validatePublishAt(args.PublishAt); msg != "" {
+		errorResponse(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	scheduled, err = cfg.dbQueries.UpdateScheduledChirp(r.Context(), args)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errorResponse(w, http.StatusNotFound, "Scheduled chirp not found")
+			return
+		}
+		log.Printf("Error updating scheduled chirp! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not edit scheduled chirp")
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, scheduledChirpResponse(scheduled))
+}
+
+// handlerCancelScheduledChirp is a POST to .../cancel because a DELETE on
+// /api/chirps/scheduled/{scheduledId} would collide with the per-chirp
+// routes like DELETE /api/chirps/{chirpId}/like.
+func (cfg *apiConfig) handlerCancelScheduledChirp(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	scheduledId, err := uuid.Parse(r.PathValue("scheduledId"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Could not use scheduled chirp id")
+		return
+	}
+
+	deleted, err := cfg.dbQueries.DeleteScheduledChirp(r.Context(), database.DeleteScheduledChirpParams{
+		ID:     scheduledId,
+		UserID: userId,
+	})
+	if err != nil {
+		log.Printf("Error cancelling scheduled chirp! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not cancel scheduled chirp")
+		return
+	}
+	if deleted == 0 {
+		errorResponse(w, http.StatusNotFound, "Scheduled chirp not found")
+		return
+	}
+
+	jsonResponse(w, http.StatusNoContent, struct{}{})
+}
+
+// publishScheduledChirps publishes every chirp that has come due. Each one
+// is claimed with SKIP LOCKED and removed in the same transaction that
+// creates the chirp, so concurrent workers can never publish it twice.
+func (cfg *apiConfig) publishScheduledChirps(ctx context.Context) error {
+	for {
+		published, err := cfg.publishNextScheduledChirp(ctx)
+		if err != nil {
+			return err
+		}
+		if !published {
+			return nil
+		}
+	}
+}
+
+func (cfg *apiConfig) publishNextScheduledChirp(ctx context.Context) (bool, error) {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	scheduled, err := qtx.ClaimDueScheduledChirp(ctx)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	chirp, err := insertScheduledChirp(ctx, qtx, scheduled)
+	if err != nil {
+		tx.Rollback()
+		log.Printf("Error publishing scheduled chirp %v :: %v", scheduled.ID, err)
+		failure := "Could not publish chirp"
+		switch err {
+		case errMediaUnavailable, errMediaMixed, errReplyUnavailable, errQuoteUnavailable:
+			failure = err.Error()
+		}
+		return true, cfg.dbQueries.FailScheduledChirp(ctx, database.FailScheduledChirpParams{
+			ID:      scheduled.ID,
+			Failure: sql.NullString{String: failure, Valid: true},
+		})
+	}
+
+	if _, err := qtx.DeleteScheduledChirp(ctx, database.DeleteScheduledChirpParams{
+		ID:     scheduled.ID,
+		UserID: scheduled.UserID,
+	}); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	cfg.publishChirp(ctx, chirp)
+	return true, nil
+}
+
+// insertScheduledChirp creates the chirp once its reply and quote targets
+// have been checked again, since they may have been deleted, hidden or had
+// their author block the user while the chirp waited.
+func insertScheduledChirp(ctx context.Context, qtx *database.Queries, scheduled database.ScheduledChirp) (database.Chirp, error) {
+	viewerId := uuid.NullUUID{UUID: scheduled.UserID, Valid: true}
+	if scheduled.InReplyTo.Valid {
+		_, err := qtx.GetChirpForViewer(ctx, database.GetChirpForViewerParams{
+			ID:       scheduled.InReplyTo.UUID,
+			ViewerID: viewerId,
+		})
+		if err == sql.ErrNoRows {
+			return database.Chirp{}, errReplyUnavailable
+		}
+		if err != nil {
+			return database.Chirp{}, err
+		}
+	}
+	if scheduled.QuoteOf.Valid {
+		_, err := qtx.GetChirpForViewer(ctx, database.GetChirpForViewerParams{
+			ID:       scheduled.QuoteOf.UUID,
+			ViewerID: viewerId,
+		})
+		if err == sql.ErrNoRows {
+			return database.Chirp{}, errQuoteUnavailable
+		}
+		if err != nil {
+			return database.Chirp{}, err
+		}
+	}
+
+	args := database.CreateChirpParams{
+		Body:           scheduled.Body,
+		UserID:         scheduled.UserID,
+		InReplyTo:      scheduled.InReplyTo,
+		ConversationID: scheduled.ConversationID,
+		QuoteOf:        scheduled.QuoteOf,
+		Visibility:     scheduled.Visibility,
+		ContentWarning: scheduled.ContentWarning,
+		Sensitive:      scheduled.Sensitive,
+	}
+	return insertChirp(ctx, qtx, args, scheduled.MediaIds)
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/geophpherie/boot-dev-chirpy-v2/internal/database"
+	"github.com/geophpherie/boot-dev-chirpy-v2/internal/pagination"
+	"github.com/geophpherie/boot-dev-chirpy-v2/internal/search"
+	"github.com/google/uuid"
+)
+
+// searchRecency is how much newer a chirp has to be to count for as much
+// as being e times more relevant.
+const searchRecency = 7 * 24 * time.Hour
+
+func (cfg *apiConfig) handlerSearchChirps(w http.ResponseWriter, r *http.Request) {
+	query, err := search.Parse(r.URL.Query().Get("q"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	viewerId, err := cfg.viewer(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	page, err := pagination.ParseQuery(r.URL.Query())
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	args := database.SearchChirpsParams{
+		Text:           query.Text,
+		RecencySeconds: searchRecency.Seconds(),
+		FromHandles:    query.From,
+		Tags:           query.Tags,
+		ViewerID:       viewerId,
+		MaxResults:     page.Limit + 1,
+	}
+	if !query.Since.IsZero() {
+		args.Since = sql.NullTime{Time: query.Since, Valid: true}
+	}
+	if !query.Until.IsZero() {
+		args.Until = sql.NullTime{Time: query.Until, Valid: true}
+	}
+	if page.Cursor != nil {
+		args.BeforeScore = sql.NullFloat64{Float64: page.Cursor.Rank, Valid: true}
+		args.BeforeID = uuid.NullUUID{UUID: page.Cursor.ID, Valid: true}
+	}
+
+	rows, err := cfg.dbQueries.SearchChirps(r.Context(), args)
+	if err != nil {
+		log.Printf("Error searching chirps! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not search chirps")
+		return
+	}
+
+	rows, nextCursor := paginate(rows, page.Limit, func(row database.SearchChirpsRow) pagination.Cursor {
+		return pagination.Cursor{Rank: row.Score, ID: row.Chirp.ID}
+	})
+
+	response := []Chirp{}
+	for _, row := range rows {
+		response = append(response, chirpResponse(row.Chirp))
+	}
+	cfg.hydrateChirps(r.Context(), viewerId, pointers(response)...)
+	pageResponse(w, r, response, nextCursor)
+}
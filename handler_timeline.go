@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/geophpherie/boot-dev-chirpy-v2/internal/database"
+	"github.com/geophpherie/boot-dev-chirpy-v2/internal/pagination"
+	"github.com/google/uuid"
+)
+
+const (
+	fanOutBatchSize        = 100
+	timelineBackfill       = 100
+	defaultFanOutThreshold = 10000
+)
+
+func (cfg *apiConfig) handlerGetTimeline(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	page, err := pagination.ParseQuery(r.URL.Query())
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	args := database.GetTimelineParams{
+		UserID:          userId,
+		MaxResults:      page.Limit + 1,
+		FanOutThreshold: cfg.fanOutThreshold,
+	}
+	args.BeforeCreatedAt, args.BeforeID = cursorArgs(page.Cursor)
+
+	chirps, err := cfg.dbQueries.GetTimeline(r.Context(), args)
+	if err != nil {
+		log.Printf("Error getting timeline! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not retrieve timeline")
+		return
+	}
+
+	cfg.chirpPageResponse(w, r, uuid.NullUUID{UUID: userId, Valid: true}, chirps, page.Limit)
+}
+
+// processFanOut copies newly created chirps into their authors' followers'
+// timelines. Authors with more followers than the threshold are skipped and
+// merged into timelines at read time instead.
+func (cfg *apiConfig) processFanOut(ctx context.Context) error {
+	for {
+		count, err := cfg.fanOutBatch(ctx)
+		if err != nil {
+			return err
+		}
+		if count < fanOutBatchSize {
+			return nil
+		}
+	}
+}
+
+func (cfg *apiConfig) fanOutBatch(ctx context.Context) (int, error) {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.dbQueries.WithTx(tx)
+
+	chirps, err := qtx.GetChirpsForFanOut(ctx, fanOutBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	ids := []uuid.UUID{}
+	for _, chirp := range chirps {
+		ids = append(ids, chirp.ID)
+		if chirp.DeletedAt.Valid {
+			continue
+		}
+
+		author, err := qtx.GetUser(ctx, chirp.UserID)
+		if err != nil {
+			return 0, err
+		}
+
+		err = qtx.AddTimelineEntry(ctx, database.AddTimelineEntryParams{
+			UserID:    chirp.UserID,
+			ChirpID:   chirp.ID,
+			AuthorID:  chirp.UserID,
+			CreatedAt: chirp.CreatedAt,
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		if author.FollowerCount <= cfg.fanOutThreshold {
+			err = qtx.FanOutChirp(ctx, database.FanOutChirpParams{
+				ChirpID:   chirp.ID,
+				AuthorID:  chirp.UserID,
+				CreatedAt: chirp.CreatedAt,
+			})
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	if err := qtx.MarkChirpsFannedOut(ctx, ids); err != nil {
+		return 0, err
+	}
+	return len(chirps), tx.Commit()
+}
+
+func (cfg *apiConfig) backfillTimeline(ctx context.Context, userId, authorId uuid.UUID) {
+	args := database.BackfillTimelineParams{
+		UserID:     userId,
+		AuthorID:   authorId,
+		MaxResults: timelineBackfill,
+	}
+	if err := cfg.dbQueries.BackfillTimeline(ctx, args); err != nil {
+		log.Printf("Error backfilling timeline for %v :: %v", userId, err)
+	}
+}
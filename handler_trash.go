@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/geophpherie/boot-dev-chirpy-v2/internal/database"
+	"github.com/geophpherie/boot-dev-chirpy-v2/internal/pagination"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultTrashRetention = 30 * 24 * time.Hour
+	purgeBatchSize        = 100
+)
+
+type TrashedChirp struct {
+	Chirp
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at"`
+}
+
+// DeletedChirp is the moderator's view of a chirp, which still shows what
+// a deleted chirp said until it has been purged.
+type DeletedChirp struct {
+	Chirp
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	PurgedAt  *time.Time `json:"purged_at,omitempty"`
+}
+
+func (cfg *apiConfig) handlerGetTrash(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	page, err := pagination.ParseQuery(r.URL.Query())
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	args := database.GetTrashedChirpsParams{
+		UserID:       userId,
+		DeletedAfter: time.Now().Add(-cfg.trashRetention),
+		MaxResults:   page.Limit + 1,
+	}
+	args.BeforeDeletedAt, args.BeforeID = cursorArgs(page.Cursor)
+
+	chirps, err := cfg.dbQueries.GetTrashedChirps(r.Context(), args)
+	if err != nil {
+		log.Printf("Error getting trash! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not retrieve trash")
+		return
+	}
+
+	chirps, nextCursor := paginate(chirps, page.Limit, func(chirp database.Chirp) pagination.Cursor {
+		return pagination.Cursor{Time: chirp.DeletedAt.Time, ID: chirp.ID}
+	})
+
+	response := []TrashedChirp{}
+	for _, chirp := range chirps {
+		response = append(response, TrashedChirp{
+			Chirp:     chirpResponse(chirp),
+			DeletedAt: chirp.DeletedAt.Time,
+			PurgeAt:   chirp.DeletedAt.Time.Add(cfg.trashRetention),
+		})
+	}
+	embedded := []*Chirp{}
+	for i := range response {
+		embedded = append(embedded, &response[i].Chirp)
+	}
+	cfg.hydrateChirps(r.Context(), uuid.NullUUID{UUID: userId, Valid: true}, embedded...)
+	pageResponse(w, r, response, nextCursor)
+}
+
+func (cfg *apiConfig) handlerRestoreChirp(w http.ResponseWriter, r *http.Request) {
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	chirpId, err := uuid.Parse(r.PathValue("chirpId"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Could not use chirp id")
+		return
+	}
+
+	chirp, err := cfg.dbQueries.RestoreChirp(r.Context(), database.RestoreChirpParams{
+		ID:           chirpId,
+		UserID:       userId,
+		DeletedAfter: time.Now().Add(-cfg.trashRetention),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errorResponse(w, http.StatusNotFound, "Chirp not found in trash")
+			return
+		}
+		log.Printf("Error restoring chirp! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not restore chirp")
+		return
+	}
+	// it may have been deleted before fan-out reached it
+	notify(cfg.fanOutWake)
+
+	response := chirpResponse(chirp)
+	cfg.hydrateChirps(r.Context(), uuid.NullUUID{UUID: userId, Valid: true}, &response)
+	jsonResponse(w, http.StatusOK, response)
+}
+
+func deletedChirpResponse(chirp database.Chirp) DeletedChirp {
+	return DeletedChirp{
+		Chirp:     chirpResponse(chirp),
+		DeletedAt: nullTime(chirp.DeletedAt),
+		PurgedAt:  nullTime(chirp.PurgedAt),
+	}
+}
+
+func (cfg *apiConfig) handlerAdminGetDeletedChirps(w http.ResponseWriter, r *http.Request) {
+	if _, err := cfg.authenticateAdmin(r); err != nil {
+		errorResponse(w, http.StatusForbidden, "forbidden")
+		return
+	}
+
+	page, err := pagination.ParseQuery(r.URL.Query())
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	args := database.GetDeletedChirpsParams{MaxResults: page.Limit + 1}
+	args.BeforeDeletedAt, args.BeforeID = cursorArgs(page.Cursor)
+
+	chirps, err := cfg.dbQueries.GetDeletedChirps(r.Context(), args)
+	if err != nil {
+		log.Printf("Error getting deleted chirps! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not retrieve chirps")
+		return
+	}
+
+	chirps, nextCursor := paginate(chirps, page.Limit, func(chirp database.Chirp) pagination.Cursor {
+		return pagination.Cursor{Time: chirp.DeletedAt.Time, ID: chirp.ID}
+	})
+
+	response := []DeletedChirp{}
+	for _, chirp := range chirps {
+		response = append(response, deletedChirpResponse(chirp))
+	}
+	pageResponse(w, r, response, nextCursor)
+}
+
+func (cfg *apiConfig) handlerAdminGetChirp(w http.ResponseWriter, r *http.Request) {
+	if _, err := cfg.authenticateAdmin(r); err != nil {
+		errorResponse(w, http.StatusForbidden, "forbidden")
+		return
+	}
+
+	chirpId, err := uuid.Parse(r.PathValue("chirpId"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Could not use chirp id")
+		return
+	}
+
+	chirp, err := cfg.dbQueries.GetChirp(r.Context(), chirpId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errorResponse(w, http.StatusNotFound, "Chirp not found")
+			return
+		}
+		log.Printf("Error getting chirp! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not retrieve chirp")
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, deletedChirpResponse(chirp))
+}
+
+// purgeTrash permanently removes chirps that have been in the trash longer
+// than the retention window. Chirps with replies are scrubbed down to
+// tombstones instead so their threads stay intact.
+func (cfg *apiConfig) purgeTrash(ctx context.Context) error {
+	cutoff := time.Now().Add(-cfg.trashRetention)
+
+	if _, err := cfg.dbQueries.DeleteExpiredChirps(ctx, cutoff); err != nil {
+		return err
+	}
+
+	for {
+		ids, err := cfg.dbQueries.GetExpiredChirpIds(ctx, database.GetExpiredChirpIdsParams{
+			DeletedBefore: cutoff,
+			MaxResults:    purgeBatchSize,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, id := range ids {
+			if err := cfg.purgeChirp(ctx, id); err != nil {
+				return err
+			}
+		}
+
+		if len(ids) < purgeBatchSize {
+			return nil
+		}
+	}
+}
+
+func (cfg *apiConfig) purgeChirp(ctx context.Context, chirpId uuid.UUID) error {
+	tx, err := cfg.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := tombstoneChirp(ctx, cfg.dbQueries.WithTx(tx), chirpId); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func tombstoneChirp(ctx context.Context, qtx *database.Queries, chirpId uuid.UUID) error {
+	if err := qtx.TombstoneChirp(ctx, chirpId); err != nil {
+		return err
+	}
+	if err := qtx.DeleteChirpRevisions(ctx, chirpId); err != nil {
+		return err
+	}
+	if err := qtx.DeleteChirpHashtags(ctx, chirpId); err != nil {
+		return err
+	}
+	if err := qtx.DeleteChirpMentions(ctx, chirpId); err != nil {
+		return err
+	}
+	if err := qtx.DetachChirpMedia(ctx, chirpId); err != nil {
+		return err
+	}
+	if err := qtx.DeleteChirpBookmarks(ctx, chirpId); err != nil {
+		return err
+	}
+	return qtx.DeleteTimelineEntriesByChirp(ctx, chirpId)
+}
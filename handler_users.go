@@ -3,15 +3,20 @@ package main
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/geophpherie/boot-dev-chirpy-v2/internal/auth"
 	"github.com/geophpherie/boot-dev-chirpy-v2/internal/database"
+	"github.com/geophpherie/boot-dev-chirpy-v2/internal/pagination"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type User struct {
@@ -19,15 +24,71 @@ type User struct {
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 	Email        string    `json:"email"`
+	Handle       string    `json:"handle,omitempty"`
+	DisplayName  string    `json:"display_name,omitempty"`
 	Token        string    `json:"token,omitempty"`
 	RefreshToken string    `json:"refresh_token,omitempty"`
 	IsRed        bool      `json:"is_chirpy_red"`
+	Status       string    `json:"status"`
+}
+
+func userResponse(user database.User) User {
+	return User{
+		ID:          user.ID,
+		CreatedAt:   user.CreatedAt,
+		UpdatedAt:   user.UpdatedAt,
+		Email:       user.Email,
+		Handle:      user.Handle.String,
+		DisplayName: user.DisplayName.String,
+		IsRed:       user.IsChirpyRed,
+		Status:      user.Status,
+	}
+}
+
+type Profile struct {
+	ID                     uuid.UUID `json:"id"`
+	CreatedAt              time.Time `json:"created_at"`
+	Handle                 string    `json:"handle,omitempty"`
+	DisplayName            string    `json:"display_name,omitempty"`
+	IsRed                  bool      `json:"is_chirpy_red"`
+	FollowerCount          int32     `json:"follower_count"`
+	FollowingCount         int32     `json:"following_count"`
+	RequiresFollowApproval bool      `json:"requires_follow_approval"`
+}
+
+// UserProfile is what the profile endpoint shows, which is a little more
+// than the profile embedded in other responses.
+type UserProfile struct {
+	Profile
+	PinnedChirps []Chirp `json:"pinned_chirps"`
+}
+
+// UserSettings adds the preferences only the user themselves gets to see.
+type UserSettings struct {
+	Profile
+	SensitiveContent string `json:"sensitive_content"`
+}
+
+func profileResponse(user database.User) Profile {
+	return Profile{
+		ID:                     user.ID,
+		CreatedAt:              user.CreatedAt,
+		Handle:                 user.Handle.String,
+		DisplayName:            user.DisplayName.String,
+		IsRed:                  user.IsChirpyRed,
+		FollowerCount:          user.FollowerCount,
+		FollowingCount:         user.FollowingCount,
+		RequiresFollowApproval: user.RequiresFollowApproval,
+	}
 }
 
 func (cfg *apiConfig) handlerNewUser(w http.ResponseWriter, r *http.Request) {
 	requestParams := struct {
-		Password string `json:"password"`
-		Email    string `json:"email"`
+		Password    string `json:"password"`
+		Email       string `json:"email"`
+		Handle      string `json:"handle"`
+		DisplayName string `json:"display_name"`
+		InviteCode  string `json:"invite_code"`
 	}{}
 
 	decoder := json.NewDecoder(r.Body)
@@ -37,6 +98,17 @@ func (cfg *apiConfig) handlerNewUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if cfg.registrationMode == registrationInvite && requestParams.InviteCode == "" {
+		errorResponse(w, http.StatusForbidden, "An invite code is required to register")
+		return
+	}
+
+	handle, err := normalizeHandle(requestParams.Handle)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	hashedPassword, err := auth.HashPassword(requestParams.Password)
 	if err != nil {
 		errorResponse(w, http.StatusInternalServerError, "Cannot handle that password")
@@ -46,24 +118,40 @@ func (cfg *apiConfig) handlerNewUser(w http.ResponseWriter, r *http.Request) {
 	params := database.CreateUserParams{
 		Email:          requestParams.Email,
 		HashedPassword: sql.NullString{String: hashedPassword, Valid: true},
+		Handle:         handle,
+		DisplayName:    optionalString(requestParams.DisplayName),
+		Status:         "active",
+	}
+
+	code := http.StatusCreated
+	if cfg.registrationMode == registrationApproval {
+		params.Status = "pending"
+		code = http.StatusAccepted
 	}
 
-	user, err := cfg.dbQueries.CreateUser(r.Context(), params)
+	var user database.User
+	if cfg.registrationMode == registrationInvite {
+		user, err = cfg.createInvitedUser(r.Context(), params, requestParams.InviteCode)
+	} else {
+		user, err = cfg.dbQueries.CreateUser(r.Context(), params)
+	}
 	if err != nil {
+		if err == errInvalidInvite {
+			errorResponse(w, http.StatusForbidden, err.Error())
+			return
+		}
+		if isUniqueViolation(err) {
+			errorResponse(w, http.StatusConflict, "Email or handle already taken")
+			return
+		}
 		log.Printf("User creation failed :: %v", err)
 		errorResponse(w, http.StatusInternalServerError, "Cannot create user")
 		return
 	}
 
-	responseUser := User{
-		ID:        user.ID,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
-		Email:     user.Email,
-		IsRed:     user.IsChirpyRed,
-	}
+	responseUser := userResponse(user)
 
-	jsonResponse(w, http.StatusCreated, responseUser)
+	jsonResponse(w, code, responseUser)
 }
 
 func (cfg *apiConfig) handlerUpdateUser(w http.ResponseWriter, r *http.Request) {
@@ -109,17 +197,242 @@ func (cfg *apiConfig) handlerUpdateUser(w http.ResponseWriter, r *http.Request)
 		errorResponse(w, http.StatusInternalServerError, "Cannot update user")
 		return
 	}
+	cfg.recordSecurityEvent(r, user.ID, securityEventAccountUpdated)
 
-	responseUser := User{
-		ID:        user.ID,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
-		Email:     user.Email,
-		IsRed:     user.IsChirpyRed,
-	}
+	responseUser := userResponse(user)
 
 	jsonResponse(w, http.StatusOK, responseUser)
 }
+
+func (cfg *apiConfig) handlerGetUser(w http.ResponseWriter, r *http.Request) {
+	userId, err := uuid.Parse(r.PathValue("userId"))
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, "Could not use user id")
+		return
+	}
+
+	viewerId, err := cfg.viewer(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	user, err := cfg.dbQueries.GetUserForViewer(r.Context(), database.GetUserForViewerParams{
+		ID:       userId,
+		ViewerID: viewerId,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			errorResponse(w, http.StatusNotFound, "User not found")
+			return
+		}
+		log.Printf("Error getting user! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to retrieve user")
+		return
+	}
+
+	pinned, err := cfg.pinnedChirps(r.Context(), userId, viewerId)
+	if err != nil {
+		log.Printf("Error getting pinned chirps! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Unable to retrieve user")
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, UserProfile{
+		Profile:      profileResponse(user),
+		PinnedChirps: pinned,
+	})
+}
+
+func (cfg *apiConfig) handlerUpdateSettings(w http.ResponseWriter, r *http.Request) {
+	requestParams := struct {
+		RequiresFollowApproval *bool   `json:"requires_follow_approval"`
+		SensitiveContent       *string `json:"sensitive_content"`
+	}{}
+
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&requestParams); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		errorResponse(w, http.StatusBadRequest, "Error decoding request body")
+		return
+	}
+
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	user, err := cfg.dbQueries.GetUser(r.Context(), userId)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	// settings left out of the request keep their current value
+	params := database.UpdateUserSettingsParams{
+		ID:                     userId,
+		RequiresFollowApproval: user.RequiresFollowApproval,
+		SensitiveContent:       user.SensitiveContent,
+	}
+	if requestParams.RequiresFollowApproval != nil {
+		if *requestParams.RequiresFollowApproval && !user.IsChirpyRed {
+			errorResponse(w, http.StatusForbidden, "Follow approval requires Chirpy Red")
+			return
+		}
+		params.RequiresFollowApproval = *requestParams.RequiresFollowApproval
+	}
+	if requestParams.SensitiveContent != nil {
+		if err := parseSensitiveContent(*requestParams.SensitiveContent); err != nil {
+			errorResponse(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		params.SensitiveContent = *requestParams.SensitiveContent
+	}
+
+	user, err = cfg.dbQueries.UpdateUserSettings(r.Context(), params)
+	if err != nil {
+		log.Printf("Settings update failed :: %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Cannot update settings")
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, UserSettings{
+		Profile:          profileResponse(user),
+		SensitiveContent: user.SensitiveContent,
+	})
+}
+
+func (cfg *apiConfig) handlerUpdateProfile(w http.ResponseWriter, r *http.Request) {
+	requestParams := struct {
+		Handle      string `json:"handle"`
+		DisplayName string `json:"display_name"`
+	}{}
+
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&requestParams); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		errorResponse(w, http.StatusBadRequest, "Error decoding request body")
+		return
+	}
+
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	handle, err := normalizeHandle(requestParams.Handle)
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	params := database.UpdateUserProfileParams{
+		ID:          userId,
+		Handle:      handle,
+		DisplayName: optionalString(requestParams.DisplayName),
+	}
+	user, err := cfg.dbQueries.UpdateUserProfile(r.Context(), params)
+	if err != nil {
+		if isUniqueViolation(err) {
+			errorResponse(w, http.StatusConflict, "Handle already taken")
+			return
+		}
+		log.Printf("Profile update failed :: %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Cannot update profile")
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, profileResponse(user))
+}
+
+func (cfg *apiConfig) handlerSearchUsers(w http.ResponseWriter, r *http.Request) {
+	query := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("q")))
+	if query == "" {
+		errorResponse(w, http.StatusBadRequest, "Search query is required")
+		return
+	}
+	query = strings.TrimPrefix(query, "@")
+
+	viewerId, err := cfg.viewer(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	page, err := pagination.ParseQuery(r.URL.Query())
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	args := database.SearchUsersParams{
+		Prefix:     likeEscaper.Replace(query),
+		Query:      query,
+		ViewerID:   viewerId,
+		MaxResults: page.Limit + 1,
+	}
+	if page.Cursor != nil {
+		args.BeforeFollowerCount = sql.NullInt32{Int32: int32(page.Cursor.Rank), Valid: true}
+		args.BeforeID = uuid.NullUUID{UUID: page.Cursor.ID, Valid: true}
+	}
+
+	users, err := cfg.dbQueries.SearchUsers(r.Context(), args)
+	if err != nil {
+		log.Printf("Error searching users! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not search users")
+		return
+	}
+
+	users, nextCursor := paginate(users, page.Limit, func(user database.User) pagination.Cursor {
+		return pagination.Cursor{Rank: float64(user.FollowerCount), ID: user.ID}
+	})
+
+	response := []Profile{}
+	for _, user := range users {
+		response = append(response, profileResponse(user))
+	}
+	pageResponse(w, r, response, nextCursor)
+}
+
+func (cfg *apiConfig) handlerGetUserDirectory(w http.ResponseWriter, r *http.Request) {
+	viewerId, err := cfg.viewer(r)
+	if err != nil {
+		errorResponse(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	page, err := pagination.ParseQuery(r.URL.Query())
+	if err != nil {
+		errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	args := database.GetRecentlyActiveUsersParams{
+		ViewerID:   viewerId,
+		MaxResults: page.Limit + 1,
+	}
+	args.BeforeActiveAt, args.BeforeID = cursorArgs(page.Cursor)
+
+	users, err := cfg.dbQueries.GetRecentlyActiveUsers(r.Context(), args)
+	if err != nil {
+		log.Printf("Error getting user directory! %v", err)
+		errorResponse(w, http.StatusInternalServerError, "Could not retrieve users")
+		return
+	}
+
+	users, nextCursor := paginate(users, page.Limit, func(user database.User) pagination.Cursor {
+		return pagination.Cursor{Time: user.LastActiveAt.Time, ID: user.ID}
+	})
+
+	response := []Profile{}
+	for _, user := range users {
+		response = append(response, profileResponse(user))
+	}
+	pageResponse(w, r, response, nextCursor)
+}
+
 func (cfg *apiConfig) handlerLogin(w http.ResponseWriter, r *http.Request) {
 	requestParams := struct {
 		Password string `json:"password"`
@@ -148,10 +461,16 @@ func (cfg *apiConfig) handlerLogin(w http.ResponseWriter, r *http.Request) {
 
 	err = auth.CheckPasswordHash(requestParams.Password, user.HashedPassword.String)
 	if err != nil {
+		cfg.recordSecurityEvent(r, user.ID, securityEventLoginFailed)
 		errorResponse(w, http.StatusUnauthorized, "Incorrect email or password")
 		return
 	}
 
+	if user.Status != "active" {
+		errorResponse(w, http.StatusForbidden, "Account is "+user.Status)
+		return
+	}
+
 	token, err := auth.MakeJWT(user.ID, cfg.secret, expiresIn)
 	if err != nil {
 		errorResponse(w, http.StatusUnauthorized, "Incorrect email or password")
@@ -175,16 +494,12 @@ func (cfg *apiConfig) handlerLogin(w http.ResponseWriter, r *http.Request) {
 		RevokedAt: sql.NullTime{Valid: false},
 	}
 	cfg.dbQueries.CreateRefreshToken(r.Context(), args)
+	cfg.recordSecurityEvent(r, user.ID, securityEventLogin)
+	cfg.dbQueries.TouchUser(r.Context(), user.ID)
 
-	responseUser := User{
-		ID:           user.ID,
-		CreatedAt:    user.CreatedAt,
-		UpdatedAt:    user.UpdatedAt,
-		Email:        user.Email,
-		Token:        token,
-		RefreshToken: refreshToken,
-		IsRed:        user.IsChirpyRed,
-	}
+	responseUser := userResponse(user)
+	responseUser.Token = token
+	responseUser.RefreshToken = refreshToken
 
 	jsonResponse(w, http.StatusOK, responseUser)
 }
@@ -237,12 +552,45 @@ func (cfg *apiConfig) handlerRevoke(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = cfg.dbQueries.RevokeRefreshToken(r.Context(), refreshToken)
+	userId, err := cfg.dbQueries.RevokeRefreshToken(r.Context(), refreshToken)
+	if err == sql.ErrNoRows {
+		jsonResponse(w, http.StatusNoContent, struct{}{})
+		return
+	}
 	if err != nil {
 		errorResponse(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
+	cfg.recordSecurityEvent(r, userId, securityEventTokenRevoked)
 
 	jsonResponse(w, http.StatusNoContent, struct{}{})
 
 }
+
+var handlePattern = regexp.MustCompile(`^[a-z0-9_]{1,30}$`)
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// normalizeHandle lowercases a requested handle and checks it is usable. An
+// empty handle is allowed and leaves the user without one.
+func normalizeHandle(handle string) (sql.NullString, error) {
+	handle = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(handle), "@"))
+	if handle == "" {
+		return sql.NullString{}, nil
+	}
+
+	if !handlePattern.MatchString(handle) {
+		return sql.NullString{}, errors.New("handles must be 1-30 letters, numbers or underscores")
+	}
+	return sql.NullString{String: handle, Valid: true}, nil
+}
+
+func optionalString(s string) sql.NullString {
+	s = strings.TrimSpace(s)
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
@@ -0,0 +1,55 @@
+package chirptext
+
+import (
+	"regexp"
+
+	"github.com/rivo/uniseg"
+	"golang.org/x/text/unicode/norm"
+)
+
+// URLWeight is what every link counts as, however long it really is, as
+// if it had been run through a shortener.
+const URLWeight = 23
+
+var urlPattern = regexp.MustCompile(`https?://\S+`)
+
+// Rules describes how chirp length is measured. It is served to clients as
+// is so their live counters agree with the server.
+type Rules struct {
+	MaxLength     int    `json:"max_length"`
+	Unit          string `json:"unit"`
+	Normalization string `json:"normalization"`
+	URLWeight     int    `json:"url_weight"`
+	URLPattern    string `json:"url_pattern"`
+}
+
+func NewRules(maxLength int) Rules {
+	return Rules{
+		MaxLength:     maxLength,
+		Unit:          "grapheme_cluster",
+		Normalization: "NFC",
+		URLWeight:     URLWeight,
+		URLPattern:    urlPattern.String(),
+	}
+}
+
+func Normalize(s string) string {
+	return norm.NFC.String(s)
+}
+
+// Length counts the user-perceived characters in body after NFC
+// normalization, with every URL counted as URLWeight.
+func (r Rules) Length(body string) int {
+	body = Normalize(body)
+
+	length, last := 0, 0
+	for _, loc := range urlPattern.FindAllStringIndex(body, -1) {
+		length += uniseg.GraphemeClusterCount(body[last:loc[0]]) + r.URLWeight
+		last = loc[1]
+	}
+	return length + uniseg.GraphemeClusterCount(body[last:])
+}
+
+func (r Rules) Allows(body string) bool {
+	return r.Length(body) <= r.MaxLength
+}
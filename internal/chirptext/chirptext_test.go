@@ -0,0 +1,51 @@
+package chirptext
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLength(t *testing.T) {
+	rules := NewRules(140)
+	cases := []struct {
+		body     string
+		expected int
+	}{
+		{"", 0},
+		{"hello", 5},
+		{"café", 4},
+		{"café", 4},
+		{"日本語", 3},
+		{"👍🏽", 1},
+		{"👨‍👩‍👧‍👦 family", 8},
+		{"🇯🇵🇫🇷", 2},
+		{"see https://example.com/a/very/long/path?with=query", 4 + URLWeight},
+		{"http://a.io and https://b.io", URLWeight + 5 + URLWeight},
+	}
+
+	for _, c := range cases {
+		if actual := rules.Length(c.body); actual != c.expected {
+			t.Errorf("Length(%q): expected %v, got %v", c.body, c.expected, actual)
+		}
+	}
+}
+
+func TestAllows(t *testing.T) {
+	rules := NewRules(140)
+
+	if !rules.Allows(strings.Repeat("👍", 140)) {
+		t.Errorf("expected 140 emoji to fit even though they are %v bytes", len(strings.Repeat("👍", 140)))
+	}
+	if rules.Allows(strings.Repeat("a", 141)) {
+		t.Errorf("expected 141 characters to be too long")
+	}
+	if !rules.Allows(strings.Repeat("a", 117) + "https://example.com/" + strings.Repeat("x", 200)) {
+		t.Errorf("expected a long URL to count as %v", URLWeight)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	if Normalize("café") != "café" {
+		t.Errorf("expected decomposed e to be composed")
+	}
+}
@@ -0,0 +1,133 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// chirpColumns must match the field order of Chirp.
+const chirpColumns = "id, created_at, updated_at, body, user_id, fanned_out_at, edited_at, in_reply_to, conversation_id, reply_count, deleted_at, like_count, rechirp_of, quote_of, purged_at, visibility, content_warning, sensitive, sensitive_forced, reply_path"
+
+// ChirpFilter describes a chirp listing. Zero values leave a condition out.
+type ChirpFilter struct {
+	AuthorIDs      []uuid.UUID
+	Since          sql.NullTime
+	Until          sql.NullTime
+	SinceID        uuid.NullUUID
+	MaxID          uuid.NullUUID
+	HasLinks       bool
+	HasMedia       bool
+	ExcludeReplies bool
+	Sensitive      sql.NullBool
+	ViewerID       uuid.NullUUID
+	Descending     bool
+	CursorTime     sql.NullTime
+	CursorID       uuid.NullUUID
+	MaxResults     int32
+}
+
+// query compiles the filter into a single parameterized statement. Only
+// placeholders are ever spliced into the SQL, never request values.
+func (f ChirpFilter) query() (string, []interface{}) {
+	var args []interface{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	viewer := arg(f.ViewerID)
+	where := []string{
+		"deleted_at IS NULL",
+		"NOT user_hidden_from(user_id, " + viewer + "::uuid)",
+		"can_list_chirp(chirps, " + viewer + "::uuid)",
+	}
+	if len(f.AuthorIDs) > 0 {
+		where = append(where, "user_id = ANY("+arg(pq.Array(f.AuthorIDs))+"::uuid[])")
+	}
+	if f.Since.Valid {
+		where = append(where, "created_at >= "+arg(f.Since)+"::timestamp")
+	}
+	if f.Until.Valid {
+		where = append(where, "created_at < "+arg(f.Until)+"::timestamp")
+	}
+	if f.SinceID.Valid {
+		where = append(where, "(created_at, id) > (SELECT c.created_at, c.id FROM chirps c WHERE c.id = "+arg(f.SinceID)+"::uuid)")
+	}
+	if f.MaxID.Valid {
+		where = append(where, "(created_at, id) <= (SELECT c.created_at, c.id FROM chirps c WHERE c.id = "+arg(f.MaxID)+"::uuid)")
+	}
+	if f.HasLinks {
+		where = append(where, `body ~* 'https?://'`)
+	}
+	if f.HasMedia {
+		where = append(where, "EXISTS (SELECT 1 FROM media_attachments m WHERE m.chirp_id = chirps.id)")
+	}
+	if f.ExcludeReplies {
+		where = append(where, "in_reply_to IS NULL")
+	}
+	if f.Sensitive.Valid {
+		where = append(where, "(sensitive OR sensitive_forced) = "+arg(f.Sensitive.Bool)+"::boolean")
+	}
+
+	op, order := ">", "ASC"
+	if f.Descending {
+		op, order = "<", "DESC"
+	}
+	if f.CursorTime.Valid {
+		where = append(where, fmt.Sprintf("(created_at, id) %v (%v::timestamp, %v::uuid)", op, arg(f.CursorTime), arg(f.CursorID)))
+	}
+
+	query := fmt.Sprintf("SELECT %v FROM chirps\nWHERE %v\nORDER BY created_at %v, id %v\nLIMIT %v",
+		chirpColumns, strings.Join(where, "\n\tAND "), order, order, arg(f.MaxResults))
+	return query, args
+}
+
+func (q *Queries) FilterChirps(ctx context.Context, filter ChirpFilter) ([]Chirp, error) {
+	query, args := filter.query()
+	rows, err := q.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.FannedOutAt,
+			&i.EditedAt,
+			&i.InReplyTo,
+			&i.ConversationID,
+			&i.ReplyCount,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.PurgedAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.SensitiveForced,
+			pq.Array(&i.ReplyPath),
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,90 @@
+package database
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestChirpFilterQueryDefaults(t *testing.T) {
+	query, args := ChirpFilter{MaxResults: 20}.query()
+
+	if !strings.Contains(query, "ORDER BY created_at ASC, id ASC") {
+		t.Errorf("expected ascending order, got %v", query)
+	}
+	if !strings.HasSuffix(query, "LIMIT $2") {
+		t.Errorf("expected limit as last placeholder, got %v", query)
+	}
+	if len(args) != 2 || args[1] != int32(20) {
+		t.Errorf("unexpected args %v", args)
+	}
+}
+
+func TestChirpFilterQueryPlaceholders(t *testing.T) {
+	filter := ChirpFilter{
+		AuthorIDs:  []uuid.UUID{uuid.New(), uuid.New()},
+		Since:      sql.NullTime{Time: time.Now().Add(-time.Hour), Valid: true},
+		Until:      sql.NullTime{Time: time.Now(), Valid: true},
+		SinceID:    uuid.NullUUID{UUID: uuid.New(), Valid: true},
+		MaxID:      uuid.NullUUID{UUID: uuid.New(), Valid: true},
+		HasLinks:   true,
+		Descending: true,
+		CursorTime: sql.NullTime{Time: time.Now(), Valid: true},
+		CursorID:   uuid.NullUUID{UUID: uuid.New(), Valid: true},
+		MaxResults: 5,
+	}
+	query, args := filter.query()
+
+	if len(args) != 9 {
+		t.Fatalf("expected 9 args, got %v", len(args))
+	}
+	for _, placeholder := range []string{"$1", "$2", "$3", "$4", "$5", "$6", "$7", "$8", "$9"} {
+		if !strings.Contains(query, placeholder) {
+			t.Errorf("expected %v in query %v", placeholder, query)
+		}
+	}
+	if !strings.Contains(query, "(created_at, id) < ($7::timestamp, $8::uuid)") {
+		t.Errorf("expected descending cursor predicate, got %v", query)
+	}
+	if !strings.Contains(query, "ORDER BY created_at DESC, id DESC") {
+		t.Errorf("expected descending order, got %v", query)
+	}
+}
+
+func TestChirpFilterQueryHasMedia(t *testing.T) {
+	query, args := ChirpFilter{HasMedia: true, MaxResults: 20}.query()
+
+	if !strings.Contains(query, "EXISTS (SELECT 1 FROM media_attachments m WHERE m.chirp_id = chirps.id)") {
+		t.Errorf("expected media predicate, got %v", query)
+	}
+	if len(args) != 2 {
+		t.Errorf("expected media filter to add no args, got %v", args)
+	}
+}
+
+func TestChirpFilterQueryVisibility(t *testing.T) {
+	viewer := uuid.NullUUID{UUID: uuid.New(), Valid: true}
+	query, args := ChirpFilter{ViewerID: viewer, MaxResults: 20}.query()
+
+	if !strings.Contains(query, "can_list_chirp(chirps, $1::uuid)") {
+		t.Errorf("expected visibility predicate on the viewer, got %v", query)
+	}
+	if len(args) != 2 || args[0] != viewer {
+		t.Errorf("expected the viewer placeholder to be shared, got %v", args)
+	}
+}
+
+func TestChirpFilterQuerySensitive(t *testing.T) {
+	filter := ChirpFilter{Sensitive: sql.NullBool{Bool: false, Valid: true}, MaxResults: 20}
+	query, args := filter.query()
+
+	if !strings.Contains(query, "(sensitive OR sensitive_forced) = $2::boolean") {
+		t.Errorf("expected sensitive predicate, got %v", query)
+	}
+	if len(args) != 3 || args[1] != false {
+		t.Errorf("unexpected args %v", args)
+	}
+}
@@ -7,29 +7,126 @@ package database
 
 import (
 	"context"
+	"database/sql"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
-VALUES(
-	gen_random_uuid(),
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, in_reply_to, conversation_id, quote_of, visibility, content_warning, sensitive, reply_path)
+SELECT
+	new_chirp.id,
 	NOW(),
 	NOW(),
-	$1,
-	$2
-	)
-RETURNING id, created_at, updated_at, body, user_id
+	$1::text,
+	$2::uuid,
+	$3::uuid,
+	COALESCE($4::uuid, new_chirp.id),
+	$5::uuid,
+	$6::text,
+	$7::text,
+	$8::boolean,
+	COALESCE((
+		SELECT parent.reply_path || parent.id FROM chirps parent
+		WHERE parent.id = $3::uuid
+	), '{}')
+FROM (SELECT gen_random_uuid() AS id) new_chirp
+RETURNING id, created_at, updated_at, body, user_id, fanned_out_at, edited_at, in_reply_to, conversation_id, reply_count, deleted_at, like_count, rechirp_of, quote_of, purged_at, visibility, content_warning, sensitive, sensitive_forced, reply_path
 `
 
 type CreateChirpParams struct {
-	Body   string
-	UserID uuid.UUID
+	Body           string
+	UserID         uuid.UUID
+	InReplyTo      uuid.NullUUID
+	ConversationID uuid.NullUUID
+	QuoteOf        uuid.NullUUID
+	Visibility     string
+	ContentWarning sql.NullString
+	Sensitive      bool
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.Body, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createChirp,
+		arg.Body,
+		arg.UserID,
+		arg.InReplyTo,
+		arg.ConversationID,
+		arg.QuoteOf,
+		arg.Visibility,
+		arg.ContentWarning,
+		arg.Sensitive,
+	)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.FannedOutAt,
+		&i.EditedAt,
+		&i.InReplyTo,
+		&i.ConversationID,
+		&i.ReplyCount,
+		&i.DeletedAt,
+		&i.LikeCount,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.PurgedAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.SensitiveForced,
+		pq.Array(&i.ReplyPath),
+	)
+	return i, err
+}
+
+const createChirpRevision = `-- name: CreateChirpRevision :exec
+INSERT INTO chirp_revisions (id, chirp_id, body, created_at)
+VALUES (
+	gen_random_uuid(),
+	$1,
+	$2,
+	$3
+	)
+`
+
+type CreateChirpRevisionParams struct {
+	ChirpID   uuid.UUID
+	Body      string
+	CreatedAt time.Time
+}
+
+func (q *Queries) CreateChirpRevision(ctx context.Context, arg CreateChirpRevisionParams) error {
+	_, err := q.db.ExecContext(ctx, createChirpRevision, arg.ChirpID, arg.Body, arg.CreatedAt)
+	return err
+}
+
+const createRechirp = `-- name: CreateRechirp :one
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, conversation_id, rechirp_of)
+SELECT
+	new_chirp.id,
+	NOW(),
+	NOW(),
+	'',
+	$1::uuid,
+	new_chirp.id,
+	$2::uuid
+FROM (SELECT gen_random_uuid() AS id) new_chirp
+ON CONFLICT (user_id, rechirp_of) WHERE rechirp_of IS NOT NULL DO NOTHING
+RETURNING id, created_at, updated_at, body, user_id, fanned_out_at, edited_at, in_reply_to, conversation_id, reply_count, deleted_at, like_count, rechirp_of, quote_of, purged_at, visibility, content_warning, sensitive, sensitive_forced, reply_path
+`
+
+type CreateRechirpParams struct {
+	UserID    uuid.UUID
+	RechirpOf uuid.UUID
+}
+
+func (q *Queries) CreateRechirp(ctx context.Context, arg CreateRechirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, createRechirp, arg.UserID, arg.RechirpOf)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -37,6 +134,21 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.FannedOutAt,
+		&i.EditedAt,
+		&i.InReplyTo,
+		&i.ConversationID,
+		&i.ReplyCount,
+		&i.DeletedAt,
+		&i.LikeCount,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.PurgedAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.SensitiveForced,
+		pq.Array(&i.ReplyPath),
 	)
 	return i, err
 }
@@ -64,12 +176,398 @@ func (q *Queries) DeleteChirp(ctx context.Context, arg DeleteChirpParams) error
 	return err
 }
 
-const getAllChirps = `-- name: GetAllChirps :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps ORDER BY created_at ASC
+const deleteChirpRevisions = `-- name: DeleteChirpRevisions :exec
+DELETE FROM chirp_revisions WHERE chirp_id = $1
+`
+
+func (q *Queries) DeleteChirpRevisions(ctx context.Context, chirpID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteChirpRevisions, chirpID)
+	return err
+}
+
+const deleteExpiredChirps = `-- name: DeleteExpiredChirps :execrows
+DELETE FROM chirps
+WHERE deleted_at < $1::timestamp
+	AND purged_at IS NULL
+	AND reply_count = 0
+`
+
+func (q *Queries) DeleteExpiredChirps(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteExpiredChirps, deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteRechirp = `-- name: DeleteRechirp :execrows
+DELETE FROM chirps WHERE user_id = $1 AND rechirp_of = $2
+`
+
+type DeleteRechirpParams struct {
+	UserID    uuid.UUID
+	RechirpOf uuid.NullUUID
+}
+
+func (q *Queries) DeleteRechirp(ctx context.Context, arg DeleteRechirpParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteRechirp, arg.UserID, arg.RechirpOf)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const editChirp = `-- name: EditChirp :one
+UPDATE chirps SET body = $2, updated_at = NOW(), edited_at = NOW()
+WHERE id = $1
+RETURNING id, created_at, updated_at, body, user_id, fanned_out_at, edited_at, in_reply_to, conversation_id, reply_count, deleted_at, like_count, rechirp_of, quote_of, purged_at, visibility, content_warning, sensitive, sensitive_forced, reply_path
+`
+
+type EditChirpParams struct {
+	ID   uuid.UUID
+	Body string
+}
+
+func (q *Queries) EditChirp(ctx context.Context, arg EditChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, editChirp, arg.ID, arg.Body)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.FannedOutAt,
+		&i.EditedAt,
+		&i.InReplyTo,
+		&i.ConversationID,
+		&i.ReplyCount,
+		&i.DeletedAt,
+		&i.LikeCount,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.PurgedAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.SensitiveForced,
+		pq.Array(&i.ReplyPath),
+	)
+	return i, err
+}
+
+const getChirp = `-- name: GetChirp :one
+SELECT id, created_at, updated_at, body, user_id, fanned_out_at, edited_at, in_reply_to, conversation_id, reply_count, deleted_at, like_count, rechirp_of, quote_of, purged_at, visibility, content_warning, sensitive, sensitive_forced, reply_path FROM chirps WHERE id = $1
+`
+
+func (q *Queries) GetChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getChirp, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.FannedOutAt,
+		&i.EditedAt,
+		&i.InReplyTo,
+		&i.ConversationID,
+		&i.ReplyCount,
+		&i.DeletedAt,
+		&i.LikeCount,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.PurgedAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.SensitiveForced,
+		pq.Array(&i.ReplyPath),
+	)
+	return i, err
+}
+
+const getChirpAncestors = `-- name: GetChirpAncestors :many
+WITH RECURSIVE ancestors AS (
+	SELECT parent.id, parent.in_reply_to, 1 AS depth
+	FROM chirps child
+	JOIN chirps parent ON parent.id = child.in_reply_to
+	WHERE child.id = $1
+	UNION ALL
+	SELECT parent.id, parent.in_reply_to, a.depth + 1
+	FROM ancestors a
+	JOIN chirps parent ON parent.id = a.in_reply_to
+)
+SELECT c.id, c.created_at, c.updated_at, c.body, c.user_id, c.fanned_out_at, c.edited_at, c.in_reply_to, c.conversation_id, c.reply_count, c.deleted_at, c.like_count, c.rechirp_of, c.quote_of, c.purged_at, c.visibility, c.content_warning, c.sensitive, c.sensitive_forced, c.reply_path, (user_hidden_from(c.user_id, $2::uuid)
+		OR NOT can_view_chirp(c, $2::uuid))::boolean AS hidden
+FROM ancestors a
+JOIN chirps c ON c.id = a.id
+ORDER BY a.depth DESC
+`
+
+type GetChirpAncestorsParams struct {
+	ID       uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+type GetChirpAncestorsRow struct {
+	Chirp  Chirp
+	Hidden bool
+}
+
+func (q *Queries) GetChirpAncestors(ctx context.Context, arg GetChirpAncestorsParams) ([]GetChirpAncestorsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpAncestors, arg.ID, arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetChirpAncestorsRow
+	for rows.Next() {
+		var i GetChirpAncestorsRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.Body,
+			&i.Chirp.UserID,
+			&i.Chirp.FannedOutAt,
+			&i.Chirp.EditedAt,
+			&i.Chirp.InReplyTo,
+			&i.Chirp.ConversationID,
+			&i.Chirp.ReplyCount,
+			&i.Chirp.DeletedAt,
+			&i.Chirp.LikeCount,
+			&i.Chirp.RechirpOf,
+			&i.Chirp.QuoteOf,
+			&i.Chirp.PurgedAt,
+			&i.Chirp.Visibility,
+			&i.Chirp.ContentWarning,
+			&i.Chirp.Sensitive,
+			&i.Chirp.SensitiveForced,
+			pq.Array(&i.Chirp.ReplyPath),
+			&i.Hidden,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpDescendants = `-- name: GetChirpDescendants :many
+SELECT c.id, c.created_at, c.updated_at, c.body, c.user_id, c.fanned_out_at, c.edited_at, c.in_reply_to, c.conversation_id, c.reply_count, c.deleted_at, c.like_count, c.rechirp_of, c.quote_of, c.purged_at, c.visibility, c.content_warning, c.sensitive, c.sensitive_forced, c.reply_path, (cardinality(c.reply_path) - cardinality(focus.reply_path))::int AS depth, (user_hidden_from(c.user_id, $1::uuid)
+		OR NOT can_view_chirp(c, $1::uuid))::boolean AS hidden
+FROM chirps focus
+JOIN chirps c ON c.conversation_id = focus.conversation_id
+WHERE focus.id = $2::uuid
+	AND focus.id = ANY(c.reply_path)
+	AND ($3::timestamp IS NULL
+		OR (c.created_at, c.id) > ($3::timestamp, $4::uuid))
+ORDER BY c.created_at ASC, c.id ASC
+LIMIT $5
+`
+
+type GetChirpDescendantsParams struct {
+	ViewerID       uuid.NullUUID
+	ID             uuid.UUID
+	AfterCreatedAt sql.NullTime
+	AfterID        uuid.NullUUID
+	MaxResults     int32
+}
+
+type GetChirpDescendantsRow struct {
+	Chirp  Chirp
+	Depth  int32
+	Hidden bool
+}
+
+func (q *Queries) GetChirpDescendants(ctx context.Context, arg GetChirpDescendantsParams) ([]GetChirpDescendantsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpDescendants,
+		arg.ViewerID,
+		arg.ID,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.MaxResults,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetChirpDescendantsRow
+	for rows.Next() {
+		var i GetChirpDescendantsRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.Body,
+			&i.Chirp.UserID,
+			&i.Chirp.FannedOutAt,
+			&i.Chirp.EditedAt,
+			&i.Chirp.InReplyTo,
+			&i.Chirp.ConversationID,
+			&i.Chirp.ReplyCount,
+			&i.Chirp.DeletedAt,
+			&i.Chirp.LikeCount,
+			&i.Chirp.RechirpOf,
+			&i.Chirp.QuoteOf,
+			&i.Chirp.PurgedAt,
+			&i.Chirp.Visibility,
+			&i.Chirp.ContentWarning,
+			&i.Chirp.Sensitive,
+			&i.Chirp.SensitiveForced,
+			pq.Array(&i.Chirp.ReplyPath),
+			&i.Depth,
+			&i.Hidden,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpForUpdate = `-- name: GetChirpForUpdate :one
+SELECT id, created_at, updated_at, body, user_id, fanned_out_at, edited_at, in_reply_to, conversation_id, reply_count, deleted_at, like_count, rechirp_of, quote_of, purged_at, visibility, content_warning, sensitive, sensitive_forced, reply_path FROM chirps WHERE id = $1 FOR UPDATE
+`
+
+func (q *Queries) GetChirpForUpdate(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getChirpForUpdate, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.FannedOutAt,
+		&i.EditedAt,
+		&i.InReplyTo,
+		&i.ConversationID,
+		&i.ReplyCount,
+		&i.DeletedAt,
+		&i.LikeCount,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.PurgedAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.SensitiveForced,
+		pq.Array(&i.ReplyPath),
+	)
+	return i, err
+}
+
+const getChirpForViewer = `-- name: GetChirpForViewer :one
+SELECT id, created_at, updated_at, body, user_id, fanned_out_at, edited_at, in_reply_to, conversation_id, reply_count, deleted_at, like_count, rechirp_of, quote_of, purged_at, visibility, content_warning, sensitive, sensitive_forced, reply_path FROM chirps
+WHERE id = $1
+	AND deleted_at IS NULL
+	AND NOT user_hidden_from(user_id, $2::uuid)
+	AND can_view_chirp(chirps, $2::uuid)
+`
+
+type GetChirpForViewerParams struct {
+	ID       uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+func (q *Queries) GetChirpForViewer(ctx context.Context, arg GetChirpForViewerParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getChirpForViewer, arg.ID, arg.ViewerID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.FannedOutAt,
+		&i.EditedAt,
+		&i.InReplyTo,
+		&i.ConversationID,
+		&i.ReplyCount,
+		&i.DeletedAt,
+		&i.LikeCount,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.PurgedAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.SensitiveForced,
+		pq.Array(&i.ReplyPath),
+	)
+	return i, err
+}
+
+const getChirpRevisions = `-- name: GetChirpRevisions :many
+SELECT id, chirp_id, body, created_at FROM chirp_revisions WHERE chirp_id = $1 ORDER BY created_at DESC
+`
+
+func (q *Queries) GetChirpRevisions(ctx context.Context, chirpID uuid.UUID) ([]ChirpRevision, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpRevisions, chirpID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ChirpRevision
+	for rows.Next() {
+		var i ChirpRevision
+		if err := rows.Scan(
+			&i.ID,
+			&i.ChirpID,
+			&i.Body,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpsByUserIdAfter = `-- name: GetChirpsByUserIdAfter :many
+SELECT id, created_at, updated_at, body, user_id, fanned_out_at, edited_at, in_reply_to, conversation_id, reply_count, deleted_at, like_count, rechirp_of, quote_of, purged_at, visibility, content_warning, sensitive, sensitive_forced, reply_path FROM chirps
+WHERE user_id = $1
+	AND deleted_at IS NULL
+	AND (created_at, id) > ($2::timestamp, $3::uuid)
+ORDER BY created_at ASC, id ASC
+LIMIT $4
 `
 
-func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getAllChirps)
+type GetChirpsByUserIdAfterParams struct {
+	UserID         uuid.UUID
+	AfterCreatedAt time.Time
+	AfterID        uuid.UUID
+	MaxResults     int32
+}
+
+func (q *Queries) GetChirpsByUserIdAfter(ctx context.Context, arg GetChirpsByUserIdAfterParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByUserIdAfter,
+		arg.UserID,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.MaxResults,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -83,6 +581,21 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.FannedOutAt,
+			&i.EditedAt,
+			&i.InReplyTo,
+			&i.ConversationID,
+			&i.ReplyCount,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.PurgedAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.SensitiveForced,
+			pq.Array(&i.ReplyPath),
 		); err != nil {
 			return nil, err
 		}
@@ -97,12 +610,16 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 	return items, nil
 }
 
-const getAllChirpsByUserId = `-- name: GetAllChirpsByUserId :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps WHERE user_id = $1 ORDER BY created_at ASC
+const getChirpsForFanOut = `-- name: GetChirpsForFanOut :many
+SELECT id, created_at, updated_at, body, user_id, fanned_out_at, edited_at, in_reply_to, conversation_id, reply_count, deleted_at, like_count, rechirp_of, quote_of, purged_at, visibility, content_warning, sensitive, sensitive_forced, reply_path FROM chirps
+WHERE fanned_out_at IS NULL
+ORDER BY created_at ASC
+LIMIT $1
+FOR UPDATE SKIP LOCKED
 `
 
-func (q *Queries) GetAllChirpsByUserId(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getAllChirpsByUserId, userID)
+func (q *Queries) GetChirpsForFanOut(ctx context.Context, limit int32) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsForFanOut, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -116,6 +633,21 @@ func (q *Queries) GetAllChirpsByUserId(ctx context.Context, userID uuid.UUID) ([
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.FannedOutAt,
+			&i.EditedAt,
+			&i.InReplyTo,
+			&i.ConversationID,
+			&i.ReplyCount,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.PurgedAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.SensitiveForced,
+			pq.Array(&i.ReplyPath),
 		); err != nil {
 			return nil, err
 		}
@@ -130,12 +662,174 @@ func (q *Queries) GetAllChirpsByUserId(ctx context.Context, userID uuid.UUID) ([
 	return items, nil
 }
 
-const getChirp = `-- name: GetChirp :one
-SELECT id, created_at, updated_at, body, user_id FROM chirps WHERE id = $1
+const getDeletedChirps = `-- name: GetDeletedChirps :many
+SELECT id, created_at, updated_at, body, user_id, fanned_out_at, edited_at, in_reply_to, conversation_id, reply_count, deleted_at, like_count, rechirp_of, quote_of, purged_at, visibility, content_warning, sensitive, sensitive_forced, reply_path FROM chirps
+WHERE deleted_at IS NOT NULL
+	AND ($1::timestamp IS NULL
+		OR (deleted_at, id) < ($1, $2::uuid))
+ORDER BY deleted_at DESC, id DESC
+LIMIT $3
 `
 
-func (q *Queries) GetChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, getChirp, id)
+type GetDeletedChirpsParams struct {
+	BeforeDeletedAt sql.NullTime
+	BeforeID        uuid.NullUUID
+	MaxResults      int32
+}
+
+func (q *Queries) GetDeletedChirps(ctx context.Context, arg GetDeletedChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getDeletedChirps, arg.BeforeDeletedAt, arg.BeforeID, arg.MaxResults)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.FannedOutAt,
+			&i.EditedAt,
+			&i.InReplyTo,
+			&i.ConversationID,
+			&i.ReplyCount,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.PurgedAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.SensitiveForced,
+			pq.Array(&i.ReplyPath),
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getEmbeddedChirps = `-- name: GetEmbeddedChirps :many
+SELECT c.id, c.created_at, c.updated_at, c.body, c.user_id, c.fanned_out_at, c.edited_at, c.in_reply_to, c.conversation_id, c.reply_count, c.deleted_at, c.like_count, c.rechirp_of, c.quote_of, c.purged_at, c.visibility, c.content_warning, c.sensitive, c.sensitive_forced, c.reply_path, (user_hidden_from(c.user_id, $1::uuid)
+		OR NOT can_view_chirp(c, $1::uuid))::boolean AS hidden
+FROM chirps c
+WHERE c.id = ANY($2::uuid[])
+`
+
+type GetEmbeddedChirpsParams struct {
+	ViewerID uuid.NullUUID
+	Ids      []uuid.UUID
+}
+
+type GetEmbeddedChirpsRow struct {
+	Chirp  Chirp
+	Hidden bool
+}
+
+func (q *Queries) GetEmbeddedChirps(ctx context.Context, arg GetEmbeddedChirpsParams) ([]GetEmbeddedChirpsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getEmbeddedChirps, arg.ViewerID, pq.Array(arg.Ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetEmbeddedChirpsRow
+	for rows.Next() {
+		var i GetEmbeddedChirpsRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.Body,
+			&i.Chirp.UserID,
+			&i.Chirp.FannedOutAt,
+			&i.Chirp.EditedAt,
+			&i.Chirp.InReplyTo,
+			&i.Chirp.ConversationID,
+			&i.Chirp.ReplyCount,
+			&i.Chirp.DeletedAt,
+			&i.Chirp.LikeCount,
+			&i.Chirp.RechirpOf,
+			&i.Chirp.QuoteOf,
+			&i.Chirp.PurgedAt,
+			&i.Chirp.Visibility,
+			&i.Chirp.ContentWarning,
+			&i.Chirp.Sensitive,
+			&i.Chirp.SensitiveForced,
+			pq.Array(&i.Chirp.ReplyPath),
+			&i.Hidden,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getExpiredChirpIds = `-- name: GetExpiredChirpIds :many
+SELECT id FROM chirps
+WHERE deleted_at < $1::timestamp
+	AND purged_at IS NULL
+ORDER BY deleted_at ASC
+LIMIT $2
+`
+
+type GetExpiredChirpIdsParams struct {
+	DeletedBefore time.Time
+	MaxResults    int32
+}
+
+func (q *Queries) GetExpiredChirpIds(ctx context.Context, arg GetExpiredChirpIdsParams) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, getExpiredChirpIds, arg.DeletedBefore, arg.MaxResults)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRechirp = `-- name: GetRechirp :one
+SELECT id, created_at, updated_at, body, user_id, fanned_out_at, edited_at, in_reply_to, conversation_id, reply_count, deleted_at, like_count, rechirp_of, quote_of, purged_at, visibility, content_warning, sensitive, sensitive_forced, reply_path FROM chirps WHERE user_id = $1 AND rechirp_of = $2
+`
+
+type GetRechirpParams struct {
+	UserID    uuid.UUID
+	RechirpOf uuid.NullUUID
+}
+
+func (q *Queries) GetRechirp(ctx context.Context, arg GetRechirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getRechirp, arg.UserID, arg.RechirpOf)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -143,6 +837,244 @@ func (q *Queries) GetChirp(ctx context.Context, id uuid.UUID) (Chirp, error) {
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.FannedOutAt,
+		&i.EditedAt,
+		&i.InReplyTo,
+		&i.ConversationID,
+		&i.ReplyCount,
+		&i.DeletedAt,
+		&i.LikeCount,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.PurgedAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.SensitiveForced,
+		pq.Array(&i.ReplyPath),
 	)
 	return i, err
 }
+
+const getThreadChirp = `-- name: GetThreadChirp :one
+SELECT c.id, c.created_at, c.updated_at, c.body, c.user_id, c.fanned_out_at, c.edited_at, c.in_reply_to, c.conversation_id, c.reply_count, c.deleted_at, c.like_count, c.rechirp_of, c.quote_of, c.purged_at, c.visibility, c.content_warning, c.sensitive, c.sensitive_forced, c.reply_path, (user_hidden_from(c.user_id, $1::uuid)
+		OR NOT can_view_chirp(c, $1::uuid))::boolean AS hidden
+FROM chirps c
+WHERE c.id = $2
+`
+
+type GetThreadChirpParams struct {
+	ViewerID uuid.NullUUID
+	ID       uuid.UUID
+}
+
+type GetThreadChirpRow struct {
+	Chirp  Chirp
+	Hidden bool
+}
+
+func (q *Queries) GetThreadChirp(ctx context.Context, arg GetThreadChirpParams) (GetThreadChirpRow, error) {
+	row := q.db.QueryRowContext(ctx, getThreadChirp, arg.ViewerID, arg.ID)
+	var i GetThreadChirpRow
+	err := row.Scan(
+		&i.Chirp.ID,
+		&i.Chirp.CreatedAt,
+		&i.Chirp.UpdatedAt,
+		&i.Chirp.Body,
+		&i.Chirp.UserID,
+		&i.Chirp.FannedOutAt,
+		&i.Chirp.EditedAt,
+		&i.Chirp.InReplyTo,
+		&i.Chirp.ConversationID,
+		&i.Chirp.ReplyCount,
+		&i.Chirp.DeletedAt,
+		&i.Chirp.LikeCount,
+		&i.Chirp.RechirpOf,
+		&i.Chirp.QuoteOf,
+		&i.Chirp.PurgedAt,
+		&i.Chirp.Visibility,
+		&i.Chirp.ContentWarning,
+		&i.Chirp.Sensitive,
+		&i.Chirp.SensitiveForced,
+		pq.Array(&i.Chirp.ReplyPath),
+		&i.Hidden,
+	)
+	return i, err
+}
+
+const getTrashedChirps = `-- name: GetTrashedChirps :many
+SELECT id, created_at, updated_at, body, user_id, fanned_out_at, edited_at, in_reply_to, conversation_id, reply_count, deleted_at, like_count, rechirp_of, quote_of, purged_at, visibility, content_warning, sensitive, sensitive_forced, reply_path FROM chirps
+WHERE user_id = $1
+	AND deleted_at > $2::timestamp
+	AND purged_at IS NULL
+	AND ($3::timestamp IS NULL
+		OR (deleted_at, id) < ($3, $4::uuid))
+ORDER BY deleted_at DESC, id DESC
+LIMIT $5
+`
+
+type GetTrashedChirpsParams struct {
+	UserID          uuid.UUID
+	DeletedAfter    time.Time
+	BeforeDeletedAt sql.NullTime
+	BeforeID        uuid.NullUUID
+	MaxResults      int32
+}
+
+func (q *Queries) GetTrashedChirps(ctx context.Context, arg GetTrashedChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getTrashedChirps,
+		arg.UserID,
+		arg.DeletedAfter,
+		arg.BeforeDeletedAt,
+		arg.BeforeID,
+		arg.MaxResults,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.FannedOutAt,
+			&i.EditedAt,
+			&i.InReplyTo,
+			&i.ConversationID,
+			&i.ReplyCount,
+			&i.DeletedAt,
+			&i.LikeCount,
+			&i.RechirpOf,
+			&i.QuoteOf,
+			&i.PurgedAt,
+			&i.Visibility,
+			&i.ContentWarning,
+			&i.Sensitive,
+			&i.SensitiveForced,
+			pq.Array(&i.ReplyPath),
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markChirpsFannedOut = `-- name: MarkChirpsFannedOut :exec
+UPDATE chirps SET fanned_out_at = NOW() WHERE id = ANY($1::uuid[])
+`
+
+func (q *Queries) MarkChirpsFannedOut(ctx context.Context, ids []uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, markChirpsFannedOut, pq.Array(ids))
+	return err
+}
+
+const restoreChirp = `-- name: RestoreChirp :one
+UPDATE chirps SET deleted_at = NULL, fanned_out_at = NULL
+WHERE id = $1
+	AND user_id = $2
+	AND deleted_at > $3::timestamp
+	AND purged_at IS NULL
+RETURNING id, created_at, updated_at, body, user_id, fanned_out_at, edited_at, in_reply_to, conversation_id, reply_count, deleted_at, like_count, rechirp_of, quote_of, purged_at, visibility, content_warning, sensitive, sensitive_forced, reply_path
+`
+
+type RestoreChirpParams struct {
+	ID           uuid.UUID
+	UserID       uuid.UUID
+	DeletedAfter time.Time
+}
+
+func (q *Queries) RestoreChirp(ctx context.Context, arg RestoreChirpParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, restoreChirp, arg.ID, arg.UserID, arg.DeletedAfter)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.FannedOutAt,
+		&i.EditedAt,
+		&i.InReplyTo,
+		&i.ConversationID,
+		&i.ReplyCount,
+		&i.DeletedAt,
+		&i.LikeCount,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.PurgedAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.SensitiveForced,
+		pq.Array(&i.ReplyPath),
+	)
+	return i, err
+}
+
+const setChirpSensitiveForced = `-- name: SetChirpSensitiveForced :one
+UPDATE chirps SET sensitive_forced = $2 WHERE id = $1
+RETURNING id, created_at, updated_at, body, user_id, fanned_out_at, edited_at, in_reply_to, conversation_id, reply_count, deleted_at, like_count, rechirp_of, quote_of, purged_at, visibility, content_warning, sensitive, sensitive_forced, reply_path
+`
+
+type SetChirpSensitiveForcedParams struct {
+	ID              uuid.UUID
+	SensitiveForced bool
+}
+
+func (q *Queries) SetChirpSensitiveForced(ctx context.Context, arg SetChirpSensitiveForcedParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, setChirpSensitiveForced, arg.ID, arg.SensitiveForced)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.FannedOutAt,
+		&i.EditedAt,
+		&i.InReplyTo,
+		&i.ConversationID,
+		&i.ReplyCount,
+		&i.DeletedAt,
+		&i.LikeCount,
+		&i.RechirpOf,
+		&i.QuoteOf,
+		&i.PurgedAt,
+		&i.Visibility,
+		&i.ContentWarning,
+		&i.Sensitive,
+		&i.SensitiveForced,
+		pq.Array(&i.ReplyPath),
+	)
+	return i, err
+}
+
+const softDeleteChirp = `-- name: SoftDeleteChirp :exec
+UPDATE chirps SET deleted_at = NOW() WHERE id = $1
+`
+
+func (q *Queries) SoftDeleteChirp(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, softDeleteChirp, id)
+	return err
+}
+
+const tombstoneChirp = `-- name: TombstoneChirp :exec
+UPDATE chirps SET body = '', updated_at = NOW(), deleted_at = COALESCE(deleted_at, NOW()), purged_at = NOW() WHERE id = $1
+`
+
+func (q *Queries) TombstoneChirp(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, tombstoneChirp, id)
+	return err
+}
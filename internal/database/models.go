@@ -11,12 +11,203 @@ import (
 	"github.com/google/uuid"
 )
 
-type Chirp struct {
+type Block struct {
+	BlockerID uuid.UUID
+	BlockedID uuid.UUID
+	CreatedAt time.Time
+}
+
+type BookmarkFolder struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	UserID    uuid.UUID
+	Name      string
+}
+
+type Bookmark struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	FolderID  uuid.NullUUID
+	CreatedAt time.Time
+}
+
+type ChirpHashtag struct {
+	ChirpID   uuid.UUID
+	HashtagID uuid.UUID
+	CreatedAt time.Time
+}
+
+type ChirpMention struct {
+	ChirpID     uuid.UUID
+	UserID      uuid.UUID
+	StartOffset int32
+	EndOffset   int32
+}
+
+type ChirpRevision struct {
+	ID        uuid.UUID
+	ChirpID   uuid.UUID
+	Body      string
+	CreatedAt time.Time
+}
+
+type ChirpSearch struct {
+	ChirpID  uuid.UUID
+	Document interface{}
+}
+
+type Chirp struct {
+	ID              uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	Body            string
+	UserID          uuid.UUID
+	FannedOutAt     sql.NullTime
+	EditedAt        sql.NullTime
+	InReplyTo       uuid.NullUUID
+	ConversationID  uuid.UUID
+	ReplyCount      int32
+	DeletedAt       sql.NullTime
+	LikeCount       int32
+	RechirpOf       uuid.NullUUID
+	QuoteOf         uuid.NullUUID
+	PurgedAt        sql.NullTime
+	Visibility      string
+	ContentWarning  sql.NullString
+	Sensitive       bool
+	SensitiveForced bool
+	ReplyPath       []uuid.UUID
+}
+
+type DataExport struct {
+	ID            uuid.UUID
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	UserID        uuid.UUID
+	Status        string
+	FilePath      sql.NullString
+	DownloadToken sql.NullString
+	ExpiresAt     sql.NullTime
+	ClaimedAt     sql.NullTime
+}
+
+type Draft struct {
+	ID             uuid.UUID
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	UserID         uuid.UUID
+	Body           string
+	InReplyTo      uuid.NullUUID
+	QuoteOf        uuid.NullUUID
+	MediaIds       []uuid.UUID
+	Version        int32
+	Visibility     string
+	ContentWarning sql.NullString
+	Sensitive      bool
+}
+
+type Follow struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	Status     string
+}
+
+type Hashtag struct {
+	ID        uuid.UUID
+	Tag       string
+	CreatedAt time.Time
+}
+
+type InviteRedemption struct {
+	ID         uuid.UUID
+	InviteCode string
+	UserID     uuid.UUID
+	RedeemedAt time.Time
+}
+
+type Invite struct {
+	Code      string
+	CreatedAt time.Time
+	CreatedBy uuid.NullUUID
+	MaxUses   int32
+	Uses      int32
+	ExpiresAt sql.NullTime
+}
+
+type Like struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type MediaAttachment struct {
+	ID            uuid.UUID
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	UserID        uuid.UUID
+	Kind          string
+	ContentType   string
+	Status        string
+	ClaimedAt     sql.NullTime
+	FilePath      string
+	ThumbnailPath sql.NullString
+	AltText       string
+	Width         sql.NullInt32
+	Height        sql.NullInt32
+	DurationMs    sql.NullInt32
+	Blurhash      sql.NullString
+	ChirpID       uuid.NullUUID
+	Position      sql.NullInt32
+	AttachedAt    sql.NullTime
+}
+
+type Mute struct {
+	MuterID   uuid.UUID
+	MutedID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type Notification struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	ActorID   uuid.UUID
+	Type      string
+	ChirpID   uuid.NullUUID
+	CreatedAt time.Time
+	ReadAt    sql.NullTime
+}
+
+type PinnedChirp struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	Position  int32
+	CreatedAt time.Time
+}
+
+type PollOption struct {
+	ChirpID   uuid.UUID
+	Position  int32
 	Body      string
+	VoteCount int32
+}
+
+type PollVote struct {
+	ChirpID   uuid.UUID
 	UserID    uuid.UUID
+	Choices   []int32
+	CreatedAt time.Time
+}
+
+type Poll struct {
+	ChirpID        uuid.UUID
+	CreatedAt      time.Time
+	MultipleChoice bool
+	ClosesAt       time.Time
+	VoterCount     int32
+	NotifiedAt     sql.NullTime
 }
 
 type RefreshToken struct {
@@ -28,11 +219,61 @@ type RefreshToken struct {
 	RevokedAt sql.NullTime
 }
 
-type User struct {
+type ScheduledChirp struct {
 	ID             uuid.UUID
 	CreatedAt      time.Time
 	UpdatedAt      time.Time
-	Email          string
-	HashedPassword sql.NullString
-	IsChirpyRed    bool
+	UserID         uuid.UUID
+	Body           string
+	InReplyTo      uuid.NullUUID
+	ConversationID uuid.NullUUID
+	QuoteOf        uuid.NullUUID
+	MediaIds       []uuid.UUID
+	PublishAt      time.Time
+	Status         string
+	Failure        sql.NullString
+	Visibility     string
+	ContentWarning sql.NullString
+	Sensitive      bool
+}
+
+type SecurityEvent struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	Event     string
+	IpAddress string
+	UserAgent string
+}
+
+type TimelineEntry struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	AuthorID  uuid.UUID
+	CreatedAt time.Time
+}
+
+type Trend struct {
+	HashtagID  uuid.UUID
+	Score      float64
+	ChirpCount int32
+	ComputedAt time.Time
+}
+
+type User struct {
+	ID                     uuid.UUID
+	CreatedAt              time.Time
+	UpdatedAt              time.Time
+	Email                  string
+	HashedPassword         sql.NullString
+	IsChirpyRed            bool
+	RequiresFollowApproval bool
+	FollowerCount          int32
+	FollowingCount         int32
+	Handle                 sql.NullString
+	DisplayName            sql.NullString
+	LastActiveAt           sql.NullTime
+	Status                 string
+	IsAdmin                bool
+	SensitiveContent       string
 }
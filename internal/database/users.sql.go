@@ -13,23 +13,35 @@ import (
 )
 
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, handle, display_name, status)
 VALUES (
 	gen_random_uuid(), 
 	NOW(), 
 	NOW(), 
 	$1,
-	$2
-) RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+	$2,
+	$3,
+	$4,
+	$5
+) RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, requires_follow_approval, follower_count, following_count, handle, display_name, last_active_at, status, is_admin, sensitive_content
 `
 
 type CreateUserParams struct {
 	Email          string
 	HashedPassword sql.NullString
+	Handle         sql.NullString
+	DisplayName    sql.NullString
+	Status         string
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.HashedPassword)
+	row := q.db.QueryRowContext(ctx, createUser,
+		arg.Email,
+		arg.HashedPassword,
+		arg.Handle,
+		arg.DisplayName,
+		arg.Status,
+	)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -38,6 +50,15 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.RequiresFollowApproval,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.Handle,
+		&i.DisplayName,
+		&i.LastActiveAt,
+		&i.Status,
+		&i.IsAdmin,
+		&i.SensitiveContent,
 	)
 	return i, err
 }
@@ -51,8 +72,155 @@ func (q *Queries) DeleteAllUsers(ctx context.Context) error {
 	return err
 }
 
+const getPendingUsers = `-- name: GetPendingUsers :many
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, requires_follow_approval, follower_count, following_count, handle, display_name, last_active_at, status, is_admin, sensitive_content FROM users
+WHERE status = 'pending'
+	AND ($1::timestamp IS NULL
+		OR (created_at, id) > ($1, $2::uuid))
+ORDER BY created_at ASC, id ASC
+LIMIT $3
+`
+
+type GetPendingUsersParams struct {
+	AfterCreatedAt sql.NullTime
+	AfterID        uuid.NullUUID
+	MaxResults     int32
+}
+
+func (q *Queries) GetPendingUsers(ctx context.Context, arg GetPendingUsersParams) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, getPendingUsers, arg.AfterCreatedAt, arg.AfterID, arg.MaxResults)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Email,
+			&i.HashedPassword,
+			&i.IsChirpyRed,
+			&i.RequiresFollowApproval,
+			&i.FollowerCount,
+			&i.FollowingCount,
+			&i.Handle,
+			&i.DisplayName,
+			&i.LastActiveAt,
+			&i.Status,
+			&i.IsAdmin,
+			&i.SensitiveContent,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRecentlyActiveUsers = `-- name: GetRecentlyActiveUsers :many
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, requires_follow_approval, follower_count, following_count, handle, display_name, last_active_at, status, is_admin, sensitive_content FROM users
+WHERE status = 'active'
+	AND last_active_at IS NOT NULL
+	AND NOT EXISTS (
+		SELECT 1 FROM blocks
+		WHERE (blocker_id = users.id AND blocked_id = $1::uuid)
+			OR (blocker_id = $1::uuid AND blocked_id = users.id)
+		)
+	AND ($2::timestamp IS NULL
+		OR (last_active_at, id) < ($2, $3::uuid))
+ORDER BY last_active_at DESC, id DESC
+LIMIT $4
+`
+
+type GetRecentlyActiveUsersParams struct {
+	ViewerID       uuid.NullUUID
+	BeforeActiveAt sql.NullTime
+	BeforeID       uuid.NullUUID
+	MaxResults     int32
+}
+
+func (q *Queries) GetRecentlyActiveUsers(ctx context.Context, arg GetRecentlyActiveUsersParams) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, getRecentlyActiveUsers,
+		arg.ViewerID,
+		arg.BeforeActiveAt,
+		arg.BeforeID,
+		arg.MaxResults,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Email,
+			&i.HashedPassword,
+			&i.IsChirpyRed,
+			&i.RequiresFollowApproval,
+			&i.FollowerCount,
+			&i.FollowingCount,
+			&i.Handle,
+			&i.DisplayName,
+			&i.LastActiveAt,
+			&i.Status,
+			&i.IsAdmin,
+			&i.SensitiveContent,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUser = `-- name: GetUser :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, requires_follow_approval, follower_count, following_count, handle, display_name, last_active_at, status, is_admin, sensitive_content FROM users WHERE id = $1
+`
+
+func (q *Queries) GetUser(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUser, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.RequiresFollowApproval,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.Handle,
+		&i.DisplayName,
+		&i.LastActiveAt,
+		&i.Status,
+		&i.IsAdmin,
+		&i.SensitiveContent,
+	)
+	return i, err
+}
+
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red FROM users WHERE email = $1 LIMIT 1
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, requires_follow_approval, follower_count, following_count, handle, display_name, last_active_at, status, is_admin, sensitive_content FROM users WHERE email = $1 LIMIT 1
 `
 
 func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
@@ -65,13 +233,200 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.RequiresFollowApproval,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.Handle,
+		&i.DisplayName,
+		&i.LastActiveAt,
+		&i.Status,
+		&i.IsAdmin,
+		&i.SensitiveContent,
+	)
+	return i, err
+}
+
+const getUserForUpdate = `-- name: GetUserForUpdate :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, requires_follow_approval, follower_count, following_count, handle, display_name, last_active_at, status, is_admin, sensitive_content FROM users WHERE id = $1 FOR UPDATE
+`
+
+func (q *Queries) GetUserForUpdate(ctx context.Context, id uuid.UUID) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserForUpdate, id)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.RequiresFollowApproval,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.Handle,
+		&i.DisplayName,
+		&i.LastActiveAt,
+		&i.Status,
+		&i.IsAdmin,
+		&i.SensitiveContent,
+	)
+	return i, err
+}
+
+const getUserForViewer = `-- name: GetUserForViewer :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, requires_follow_approval, follower_count, following_count, handle, display_name, last_active_at, status, is_admin, sensitive_content FROM users
+WHERE id = $1
+	AND status = 'active'
+	AND NOT user_hidden_from(id, $2::uuid)
+`
+
+type GetUserForViewerParams struct {
+	ID       uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+func (q *Queries) GetUserForViewer(ctx context.Context, arg GetUserForViewerParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserForViewer, arg.ID, arg.ViewerID)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.RequiresFollowApproval,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.Handle,
+		&i.DisplayName,
+		&i.LastActiveAt,
+		&i.Status,
+		&i.IsAdmin,
+		&i.SensitiveContent,
+	)
+	return i, err
+}
+
+const searchUsers = `-- name: SearchUsers :many
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, requires_follow_approval, follower_count, following_count, handle, display_name, last_active_at, status, is_admin, sensitive_content FROM users
+WHERE status = 'active'
+	AND (handle LIKE $1::text || '%'
+		OR lower(display_name) LIKE $1::text || '%'
+		OR handle % $2::text
+		OR lower(display_name) % $2::text)
+	AND NOT EXISTS (
+		SELECT 1 FROM blocks
+		WHERE (blocker_id = users.id AND blocked_id = $3::uuid)
+			OR (blocker_id = $3::uuid AND blocked_id = users.id)
+		)
+	AND ($4::int IS NULL
+		OR (follower_count, id) < ($4, $5::uuid))
+ORDER BY follower_count DESC, id DESC
+LIMIT $6
+`
+
+type SearchUsersParams struct {
+	Prefix              string
+	Query               string
+	ViewerID            uuid.NullUUID
+	BeforeFollowerCount sql.NullInt32
+	BeforeID            uuid.NullUUID
+	MaxResults          int32
+}
+
+func (q *Queries) SearchUsers(ctx context.Context, arg SearchUsersParams) ([]User, error) {
+	rows, err := q.db.QueryContext(ctx, searchUsers,
+		arg.Prefix,
+		arg.Query,
+		arg.ViewerID,
+		arg.BeforeFollowerCount,
+		arg.BeforeID,
+		arg.MaxResults,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []User
+	for rows.Next() {
+		var i User
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Email,
+			&i.HashedPassword,
+			&i.IsChirpyRed,
+			&i.RequiresFollowApproval,
+			&i.FollowerCount,
+			&i.FollowingCount,
+			&i.Handle,
+			&i.DisplayName,
+			&i.LastActiveAt,
+			&i.Status,
+			&i.IsAdmin,
+			&i.SensitiveContent,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setPendingUserStatus = `-- name: SetPendingUserStatus :one
+UPDATE users SET status = $2, updated_at = NOW()
+WHERE id = $1 AND status = 'pending'
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, requires_follow_approval, follower_count, following_count, handle, display_name, last_active_at, status, is_admin, sensitive_content
+`
+
+type SetPendingUserStatusParams struct {
+	ID     uuid.UUID
+	Status string
+}
+
+func (q *Queries) SetPendingUserStatus(ctx context.Context, arg SetPendingUserStatusParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setPendingUserStatus, arg.ID, arg.Status)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.RequiresFollowApproval,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.Handle,
+		&i.DisplayName,
+		&i.LastActiveAt,
+		&i.Status,
+		&i.IsAdmin,
+		&i.SensitiveContent,
 	)
 	return i, err
 }
 
+const touchUser = `-- name: TouchUser :exec
+UPDATE users SET last_active_at = NOW() WHERE id = $1
+`
+
+func (q *Queries) TouchUser(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, touchUser, id)
+	return err
+}
+
 const updateUser = `-- name: UpdateUser :one
 UPDATE users SET email = $2, hashed_password = $3 WHERE id = $1
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, requires_follow_approval, follower_count, following_count, handle, display_name, last_active_at, status, is_admin, sensitive_content
 `
 
 type UpdateUserParams struct {
@@ -90,6 +445,83 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.RequiresFollowApproval,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.Handle,
+		&i.DisplayName,
+		&i.LastActiveAt,
+		&i.Status,
+		&i.IsAdmin,
+		&i.SensitiveContent,
+	)
+	return i, err
+}
+
+const updateUserProfile = `-- name: UpdateUserProfile :one
+UPDATE users SET handle = $2, display_name = $3, updated_at = NOW() WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, requires_follow_approval, follower_count, following_count, handle, display_name, last_active_at, status, is_admin, sensitive_content
+`
+
+type UpdateUserProfileParams struct {
+	ID          uuid.UUID
+	Handle      sql.NullString
+	DisplayName sql.NullString
+}
+
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserProfile, arg.ID, arg.Handle, arg.DisplayName)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.RequiresFollowApproval,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.Handle,
+		&i.DisplayName,
+		&i.LastActiveAt,
+		&i.Status,
+		&i.IsAdmin,
+		&i.SensitiveContent,
+	)
+	return i, err
+}
+
+const updateUserSettings = `-- name: UpdateUserSettings :one
+UPDATE users SET requires_follow_approval = $2, sensitive_content = $3, updated_at = NOW() WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, requires_follow_approval, follower_count, following_count, handle, display_name, last_active_at, status, is_admin, sensitive_content
+`
+
+type UpdateUserSettingsParams struct {
+	ID                     uuid.UUID
+	RequiresFollowApproval bool
+	SensitiveContent       string
+}
+
+func (q *Queries) UpdateUserSettings(ctx context.Context, arg UpdateUserSettingsParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserSettings, arg.ID, arg.RequiresFollowApproval, arg.SensitiveContent)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.RequiresFollowApproval,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.Handle,
+		&i.DisplayName,
+		&i.LastActiveAt,
+		&i.Status,
+		&i.IsAdmin,
+		&i.SensitiveContent,
 	)
 	return i, err
 }
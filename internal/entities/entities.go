@@ -0,0 +1,81 @@
+package entities
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	MaxHashtagLength = 100
+	MaxHandleLength  = 30
+)
+
+// a hashtag starts at the beginning of the body or after a character that
+// can't be part of a word, and needs at least one letter so "#1" isn't one
+var hashtagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&/#])#([\p{L}\p{N}_]*[\p{L}_][\p{L}\p{N}_]*)`)
+
+// a mention takes the whole word after the "@" so that "@zoë" or "@a@b"
+// is turned down as a whole instead of matching a shorter handle
+var mentionPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_@])@([\p{L}\p{N}_]+)(@?)`)
+
+var handlePattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// Mention is an @handle in a chirp body. Start and End are offsets in code
+// points, not bytes, so clients in any language can slice the body with them.
+type Mention struct {
+	Handle string
+	Start  int
+	End    int
+}
+
+// NormalizeHashtag returns the stored form of a tag, with or without its
+// leading "#", or "" if it isn't a valid tag.
+func NormalizeHashtag(tag string) string {
+	tag = strings.TrimPrefix(tag, "#")
+	match := hashtagPattern.FindStringSubmatch("#" + tag)
+	if match == nil || match[1] != tag || len(tag) > MaxHashtagLength {
+		return ""
+	}
+	return strings.ToLower(tag)
+}
+
+// Hashtags returns the distinct normalized hashtags in body in the order
+// they first appear.
+func Hashtags(body string) []string {
+	tags := []string{}
+	seen := map[string]bool{}
+	for _, match := range hashtagPattern.FindAllStringSubmatch(body, -1) {
+		if len(match[1]) > MaxHashtagLength {
+			continue
+		}
+		tag := strings.ToLower(match[1])
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
+
+// Mentions returns every @handle in body in order, with handles lowercased.
+// Anything that can't be a handle, like "@zoë" or "@a@b", is left alone.
+func Mentions(body string) []Mention {
+	mentions := []Mention{}
+	for _, match := range mentionPattern.FindAllStringSubmatchIndex(body, -1) {
+		handleStart, handleEnd := match[2], match[3]
+		handle := body[handleStart:handleEnd]
+		if match[5] > match[4] || len(handle) > MaxHandleLength || !handlePattern.MatchString(handle) {
+			continue
+		}
+
+		start := utf8.RuneCountInString(body[:handleStart-1])
+		mentions = append(mentions, Mention{
+			Handle: strings.ToLower(handle),
+			Start:  start,
+			End:    start + 1 + handleEnd - handleStart,
+		})
+	}
+	return mentions
+}
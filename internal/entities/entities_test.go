@@ -0,0 +1,91 @@
+package entities
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestHashtags(t *testing.T) {
+	cases := []struct {
+		body     string
+		expected []string
+	}{
+		{"no tags here", []string{}},
+		{"#Go is great", []string{"go"}},
+		{"loving #go and #GO and #golang", []string{"go", "golang"}},
+		{"ends with #tag.", []string{"tag"}},
+		{"numbers #1 #2024 #v2", []string{"v2"}},
+		{"not a tag: a#b or &#39; or http://x.io/#frag", []string{}},
+		{"unicode #café #日本", []string{"café", "日本"}},
+		{"snake #snake_case", []string{"snake_case"}},
+		{"##double", []string{}},
+	}
+
+	for _, c := range cases {
+		actual := Hashtags(c.body)
+		if !slices.Equal(actual, c.expected) {
+			t.Errorf("Hashtags(%q): expected %v, got %v", c.body, c.expected, actual)
+		}
+	}
+}
+
+func TestHashtagsTooLong(t *testing.T) {
+	body := "#" + strings.Repeat("a", MaxHashtagLength+1)
+	if tags := Hashtags(body); len(tags) != 0 {
+		t.Errorf("expected no tags, got %v", tags)
+	}
+}
+
+func TestNormalizeHashtag(t *testing.T) {
+	cases := map[string]string{
+		"#Go":     "go",
+		"Go":      "go",
+		"2024":    "",
+		"two tag": "",
+		"":        "",
+	}
+
+	for tag, expected := range cases {
+		if actual := NormalizeHashtag(tag); actual != expected {
+			t.Errorf("NormalizeHashtag(%q): expected %q, got %q", tag, expected, actual)
+		}
+	}
+}
+
+func TestMentions(t *testing.T) {
+	cases := []struct {
+		body     string
+		expected []Mention
+	}{
+		{"no mentions", []Mention{}},
+		{"@Alice hi", []Mention{{"alice", 0, 6}}},
+		{"hi @bob and @carol_1!", []Mention{{"bob", 3, 7}, {"carol_1", 12, 20}}},
+		{"@a @b", []Mention{{"a", 0, 2}, {"b", 3, 5}}},
+		{"mail me@example.com or @@x or @a@b", []Mention{}},
+		{"@a@b @c", []Mention{{"c", 5, 7}}},
+		{"café @zoë", []Mention{}},
+		{"日本 @dan", []Mention{{"dan", 3, 7}}},
+		{"@" + strings.Repeat("a", MaxHandleLength+1), []Mention{}},
+	}
+
+	for _, c := range cases {
+		actual := Mentions(c.body)
+		if !slices.Equal(actual, c.expected) {
+			t.Errorf("Mentions(%q): expected %v, got %v", c.body, c.expected, actual)
+		}
+	}
+}
+
+func TestMentionOffsetsSliceBody(t *testing.T) {
+	body := "🎉 party with @dana"
+	mentions := Mentions(body)
+	if len(mentions) != 1 {
+		t.Fatalf("expected 1 mention, got %v", mentions)
+	}
+
+	runes := []rune(body)
+	if text := string(runes[mentions[0].Start:mentions[0].End]); text != "@dana" {
+		t.Errorf("expected offsets to cover @dana, got %q", text)
+	}
+}
@@ -0,0 +1,108 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+var ErrEntryClosed = errors.New("archive entry already closed")
+
+// Archive writes a zip file one entry at a time. Only the most recently
+// created entry may be written to, so each entry must be finished before
+// the next one is started.
+type Archive struct {
+	zw *zip.Writer
+}
+
+func NewArchive(w io.Writer) *Archive {
+	return &Archive{zw: zip.NewWriter(w)}
+}
+
+func (a *Archive) WriteJSON(name string, v any) error {
+	w, err := a.zw.Create(name)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// JSONArray streams values into a JSON array so callers never need to hold
+// the whole collection in memory.
+type JSONArray struct {
+	w      io.Writer
+	count  int
+	closed bool
+}
+
+func (a *Archive) CreateJSONArray(name string) (*JSONArray, error) {
+	w, err := a.zw.Create(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := io.WriteString(w, "["); err != nil {
+		return nil, err
+	}
+	return &JSONArray{w: w}, nil
+}
+
+func (j *JSONArray) Append(v any) error {
+	if j.closed {
+		return ErrEntryClosed
+	}
+
+	dat, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	sep := ",\n  "
+	if j.count == 0 {
+		sep = "\n  "
+	}
+	if _, err := io.WriteString(j.w, sep); err != nil {
+		return err
+	}
+	if _, err := j.w.Write(dat); err != nil {
+		return err
+	}
+	j.count++
+	return nil
+}
+
+func (j *JSONArray) Close() error {
+	if j.closed {
+		return ErrEntryClosed
+	}
+	j.closed = true
+
+	end := "\n]\n"
+	if j.count == 0 {
+		end = "]\n"
+	}
+	_, err := io.WriteString(j.w, end)
+	return err
+}
+
+func (a *Archive) CreateCSV(name string, header []string) (*csv.Writer, error) {
+	w, err := a.zw.Create(name)
+	if err != nil {
+		return nil, err
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return nil, err
+	}
+	return cw, nil
+}
+
+func (a *Archive) Close() error {
+	return a.zw.Close()
+}
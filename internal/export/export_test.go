@@ -0,0 +1,104 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"testing"
+)
+
+func readEntry(t *testing.T, zr *zip.Reader, name string) []byte {
+	for _, f := range zr.File {
+		if f.Name != name {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("can't open %v: %v", name, err)
+		}
+		defer rc.Close()
+		dat, err := io.ReadAll(rc)
+		if err != nil {
+			t.Fatalf("can't read %v: %v", name, err)
+		}
+		return dat
+	}
+	t.Fatalf("%v not found in archive", name)
+	return nil
+}
+
+func TestArchive(t *testing.T) {
+	buf := &bytes.Buffer{}
+	archive := NewArchive(buf)
+
+	if err := archive.WriteJSON("profile.json", map[string]string{"email": "a@b.c"}); err != nil {
+		t.Fatalf("error writing profile %v", err)
+	}
+
+	chirps, err := archive.CreateJSONArray("chirps.json")
+	if err != nil {
+		t.Fatalf("error creating array %v", err)
+	}
+	for _, body := range []string{"one", "two", "three"} {
+		if err := chirps.Append(map[string]string{"body": body}); err != nil {
+			t.Fatalf("error appending %v", err)
+		}
+	}
+	if err := chirps.Close(); err != nil {
+		t.Fatalf("error closing array %v", err)
+	}
+
+	if err := chirps.Append("late"); err != ErrEntryClosed {
+		t.Errorf("expected ErrEntryClosed, got %v", err)
+	}
+
+	empty, err := archive.CreateJSONArray("empty.json")
+	if err != nil {
+		t.Fatalf("error creating array %v", err)
+	}
+	if err := empty.Close(); err != nil {
+		t.Fatalf("error closing array %v", err)
+	}
+
+	cw, err := archive.CreateCSV("chirps.csv", []string{"id", "body"})
+	if err != nil {
+		t.Fatalf("error creating csv %v", err)
+	}
+	cw.Write([]string{"1", "hello, world"})
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		t.Fatalf("error writing csv %v", err)
+	}
+
+	if err := archive.Close(); err != nil {
+		t.Fatalf("error closing archive %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("archive is not a valid zip %v", err)
+	}
+
+	var decoded []map[string]string
+	if err := json.Unmarshal(readEntry(t, zr, "chirps.json"), &decoded); err != nil {
+		t.Fatalf("chirps.json is not valid json %v", err)
+	}
+	if len(decoded) != 3 || decoded[2]["body"] != "three" {
+		t.Errorf("unexpected chirps %v", decoded)
+	}
+
+	var none []map[string]string
+	if err := json.Unmarshal(readEntry(t, zr, "empty.json"), &none); err != nil || len(none) != 0 {
+		t.Errorf("expected empty array, got %v (%v)", none, err)
+	}
+
+	records, err := csv.NewReader(bytes.NewReader(readEntry(t, zr, "chirps.csv"))).ReadAll()
+	if err != nil {
+		t.Fatalf("chirps.csv is not valid csv %v", err)
+	}
+	if len(records) != 2 || records[1][1] != "hello, world" {
+		t.Errorf("unexpected csv records %v", records)
+	}
+}
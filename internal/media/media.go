@@ -0,0 +1,109 @@
+package media
+
+import (
+	"errors"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	KindImage = "image"
+	KindGIF   = "gif"
+	KindVideo = "video"
+)
+
+// MaxPixels bounds the images we are willing to decode so a small file
+// can't claim enormous dimensions and exhaust memory.
+const MaxPixels = 40_000_000
+
+var ErrUnsupportedType = errors.New("unsupported media type")
+var ErrTooLarge = errors.New("image dimensions are too large")
+
+// Info is the metadata extracted while processing an upload. Duration is
+// only set for video.
+type Info struct {
+	Width    int
+	Height   int
+	Duration time.Duration
+}
+
+// Detect sniffs the first bytes of an upload and reports what kind of
+// attachment it is. The client's declared content type is never trusted.
+func Detect(header []byte) (kind, contentType string, err error) {
+	contentType = http.DetectContentType(header)
+	switch contentType {
+	case "image/jpeg", "image/png":
+		return KindImage, contentType, nil
+	case "image/gif":
+		return KindGIF, contentType, nil
+	case "video/mp4":
+		return KindVideo, contentType, nil
+	}
+	return "", "", ErrUnsupportedType
+}
+
+// DecodeImage decodes a JPEG, PNG or the first frame of a GIF after
+// checking its dimensions against MaxPixels.
+func DecodeImage(r io.ReadSeeker) (image.Image, error) {
+	config, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return nil, err
+	}
+	if config.Width*config.Height > MaxPixels {
+		return nil, ErrTooLarge
+	}
+
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(r)
+	return img, err
+}
+
+// Thumbnail scales img down to fit within size×size, averaging the source
+// pixels that fall under each output pixel. Images already small enough
+// are copied at their own size.
+func Thumbnail(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+
+	dstW, dstH := srcW, srcH
+	if srcW > size || srcH > size {
+		if srcW >= srcH {
+			dstW, dstH = size, max(1, srcH*size/srcW)
+		} else {
+			dstW, dstH = max(1, srcW*size/srcH), size
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+	for y := 0; y < dstH; y++ {
+		y0 := bounds.Min.Y + y*srcH/dstH
+		y1 := max(y0+1, bounds.Min.Y+(y+1)*srcH/dstH)
+		for x := 0; x < dstW; x++ {
+			x0 := bounds.Min.X + x*srcW/dstW
+			x1 := max(x0+1, bounds.Min.X+(x+1)*srcW/dstW)
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := img.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+
+			i := dst.PixOffset(x, y)
+			dst.Pix[i+0] = uint8(r / n >> 8)
+			dst.Pix[i+1] = uint8(g / n >> 8)
+			dst.Pix[i+2] = uint8(b / n >> 8)
+			dst.Pix[i+3] = uint8(a / n >> 8)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,136 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+	"time"
+)
+
+func solidImage(width, height int, c color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestDetect(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, solidImage(2, 2, color.White)); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		header []byte
+		kind   string
+		err    error
+	}{
+		{buf.Bytes(), KindImage, nil},
+		{[]byte("GIF89a\x01\x00\x01\x00"), KindGIF, nil},
+		{[]byte("\x00\x00\x00\x18ftypmp42\x00\x00\x00\x00mp42isom"), KindVideo, nil},
+		{[]byte("just some text"), "", ErrUnsupportedType},
+	}
+
+	for _, c := range cases {
+		kind, _, err := Detect(c.header)
+		if kind != c.kind || err != c.err {
+			t.Errorf("Detect(%q): expected %q, %v, got %q, %v", c.header[:8], c.kind, c.err, kind, err)
+		}
+	}
+}
+
+func TestDecodeImage(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, solidImage(3, 2, color.Black)); err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := DecodeImage(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() != 3 || img.Bounds().Dy() != 2 {
+		t.Errorf("unexpected bounds %v", img.Bounds())
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	cases := []struct {
+		width, height int
+		expectedW     int
+		expectedH     int
+	}{
+		{800, 400, 100, 50},
+		{400, 800, 50, 100},
+		{60, 30, 60, 30},
+		{1000, 1, 100, 1},
+	}
+
+	for _, c := range cases {
+		thumb := Thumbnail(solidImage(c.width, c.height, color.White), 100)
+		if thumb.Bounds().Dx() != c.expectedW || thumb.Bounds().Dy() != c.expectedH {
+			t.Errorf("Thumbnail(%vx%v): expected %vx%v, got %v", c.width, c.height, c.expectedW, c.expectedH, thumb.Bounds())
+		}
+	}
+
+	thumb := Thumbnail(solidImage(10, 10, color.RGBA{R: 200, G: 100, B: 50, A: 255}), 5)
+	if r, g, b, _ := thumb.At(2, 2).RGBA(); r>>8 != 200 || g>>8 != 100 || b>>8 != 50 {
+		t.Errorf("expected colour to survive scaling, got %v %v %v", r>>8, g>>8, b>>8)
+	}
+}
+
+func TestBlurhash(t *testing.T) {
+	hash := Blurhash(solidImage(8, 6, color.Black), 4, 3)
+	expected := "L00000" + strings.Repeat("fQ", 11)
+	if hash != expected {
+		t.Errorf("expected %v, got %v", expected, hash)
+	}
+
+	hash = Blurhash(solidImage(8, 6, color.White), 1, 1)
+	if hash != "00TSUA" {
+		t.Errorf("expected 00TSUA, got %v", hash)
+	}
+}
+
+func box(boxType string, body ...[]byte) []byte {
+	content := bytes.Join(body, nil)
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header, uint32(8+len(content)))
+	copy(header[4:], boxType)
+	return append(header, content...)
+}
+
+func TestProbeVideo(t *testing.T) {
+	mvhd := make([]byte, 100)
+	binary.BigEndian.PutUint32(mvhd[12:], 1000)
+	binary.BigEndian.PutUint32(mvhd[16:], 12500)
+
+	tkhd := make([]byte, 84)
+	binary.BigEndian.PutUint32(tkhd[76:], 1280<<16)
+	binary.BigEndian.PutUint32(tkhd[80:], 720<<16)
+
+	file := bytes.Join([][]byte{
+		box("ftyp", []byte("isom")),
+		box("mdat", make([]byte, 32)),
+		box("moov", box("mvhd", mvhd), box("trak", box("tkhd", tkhd))),
+	}, nil)
+
+	info, err := ProbeVideo(bytes.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Width != 1280 || info.Height != 720 || info.Duration != 12500*time.Millisecond {
+		t.Errorf("unexpected info %+v", info)
+	}
+
+	if _, err := ProbeVideo(bytes.NewReader(box("ftyp", []byte("isom")))); err != ErrInvalidVideo {
+		t.Errorf("expected ErrInvalidVideo without moov, got %v", err)
+	}
+}
@@ -0,0 +1,167 @@
+package media
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"time"
+)
+
+// maxMoovSize caps how much of a video's metadata we read into memory.
+const maxMoovSize = 8 << 20
+
+var ErrInvalidVideo = errors.New("could not read video metadata")
+
+// ProbeVideo reads the duration and display size of an MP4 from its moov
+// box without decoding any frames. The size is taken from the first track
+// that has one, which is the video track for any file we'd accept.
+func ProbeVideo(r io.ReadSeeker) (Info, error) {
+	moov, err := findTopLevelBox(r, "moov")
+	if err != nil {
+		return Info{}, err
+	}
+
+	info := Info{}
+	foundDuration := false
+	err = walkBoxes(moov, func(boxType string, body []byte) error {
+		switch boxType {
+		case "mvhd":
+			duration, err := parseMvhd(body)
+			if err != nil {
+				return err
+			}
+			info.Duration = duration
+			foundDuration = true
+		case "trak":
+			return walkBoxes(body, func(boxType string, body []byte) error {
+				if boxType != "tkhd" || info.Width != 0 {
+					return nil
+				}
+				width, height, err := parseTkhd(body)
+				if err != nil {
+					return err
+				}
+				info.Width, info.Height = width, height
+				return nil
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return Info{}, err
+	}
+	if !foundDuration || info.Width == 0 || info.Height == 0 {
+		return Info{}, ErrInvalidVideo
+	}
+	return info, nil
+}
+
+func findTopLevelBox(r io.ReadSeeker, want string) ([]byte, error) {
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 16)
+	for {
+		if _, err := io.ReadFull(r, header[:8]); err != nil {
+			return nil, ErrInvalidVideo
+		}
+		size := uint64(binary.BigEndian.Uint32(header[:4]))
+		boxType := string(header[4:8])
+		headerSize := uint64(8)
+		if size == 1 {
+			if _, err := io.ReadFull(r, header[8:16]); err != nil {
+				return nil, ErrInvalidVideo
+			}
+			size = binary.BigEndian.Uint64(header[8:16])
+			headerSize = 16
+		}
+		if size == 0 && boxType != want {
+			return nil, ErrInvalidVideo
+		}
+		if size != 0 && size < headerSize {
+			return nil, ErrInvalidVideo
+		}
+
+		if boxType == want {
+			if size == 0 {
+				return io.ReadAll(io.LimitReader(r, maxMoovSize))
+			}
+			if size-headerSize > maxMoovSize {
+				return nil, ErrInvalidVideo
+			}
+			body := make([]byte, size-headerSize)
+			if _, err := io.ReadFull(r, body); err != nil {
+				return nil, ErrInvalidVideo
+			}
+			return body, nil
+		}
+
+		if _, err := r.Seek(int64(size-headerSize), io.SeekCurrent); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func walkBoxes(data []byte, fn func(boxType string, body []byte) error) error {
+	for len(data) >= 8 {
+		size := uint64(binary.BigEndian.Uint32(data[:4]))
+		boxType := string(data[4:8])
+		headerSize := uint64(8)
+		if size == 1 {
+			if len(data) < 16 {
+				return ErrInvalidVideo
+			}
+			size = binary.BigEndian.Uint64(data[8:16])
+			headerSize = 16
+		} else if size == 0 {
+			size = uint64(len(data))
+		}
+		if size < headerSize || size > uint64(len(data)) {
+			return ErrInvalidVideo
+		}
+
+		if err := fn(boxType, data[headerSize:size]); err != nil {
+			return err
+		}
+		data = data[size:]
+	}
+	return nil
+}
+
+func parseMvhd(body []byte) (time.Duration, error) {
+	var timescale, duration uint64
+	switch {
+	case len(body) >= 20 && body[0] == 0:
+		timescale = uint64(binary.BigEndian.Uint32(body[12:16]))
+		duration = uint64(binary.BigEndian.Uint32(body[16:20]))
+	case len(body) >= 32 && body[0] == 1:
+		timescale = uint64(binary.BigEndian.Uint32(body[20:24]))
+		duration = binary.BigEndian.Uint64(body[24:32])
+	default:
+		return 0, ErrInvalidVideo
+	}
+	if timescale == 0 {
+		return 0, ErrInvalidVideo
+	}
+	return time.Duration(float64(duration) / float64(timescale) * float64(time.Second)), nil
+}
+
+func parseTkhd(body []byte) (width, height int, err error) {
+	offset := 0
+	switch {
+	case len(body) > 0 && body[0] == 0:
+		offset = 4 + 20
+	case len(body) > 0 && body[0] == 1:
+		offset = 4 + 32
+	default:
+		return 0, 0, ErrInvalidVideo
+	}
+	offset += 52
+	if len(body) < offset+8 {
+		return 0, 0, ErrInvalidVideo
+	}
+	width = int(binary.BigEndian.Uint32(body[offset:offset+4]) >> 16)
+	height = int(binary.BigEndian.Uint32(body[offset+4:offset+8]) >> 16)
+	return width, height, nil
+}
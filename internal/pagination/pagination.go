@@ -0,0 +1,76 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+var ErrInvalidLimit = errors.New("invalid limit")
+
+// Cursor marks the last row of a page so the next page can resume after
+// it. Listings ordered by a score rather than a timestamp use Rank. Clients
+// only ever see its opaque encoding.
+type Cursor struct {
+	Time time.Time `json:"t"`
+	Rank float64   `json:"r,omitempty"`
+	ID   uuid.UUID `json:"id"`
+}
+
+func (c Cursor) Encode() string {
+	dat, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(dat)
+}
+
+func DecodeCursor(s string) (Cursor, error) {
+	dat, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(dat, &c); err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+type Page struct {
+	Limit  int32
+	Cursor *Cursor
+}
+
+// ParseQuery reads the limit and cursor parameters shared by every paginated
+// endpoint.
+func ParseQuery(query url.Values) (Page, error) {
+	page := Page{Limit: DefaultLimit}
+
+	if s := query.Get("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit < 1 || limit > MaxLimit {
+			return Page{}, ErrInvalidLimit
+		}
+		page.Limit = int32(limit)
+	}
+
+	if s := query.Get("cursor"); s != "" {
+		cursor, err := DecodeCursor(s)
+		if err != nil {
+			return Page{}, err
+		}
+		page.Cursor = &cursor
+	}
+
+	return page, nil
+}
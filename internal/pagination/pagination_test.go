@@ -0,0 +1,71 @@
+package pagination
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	expected := Cursor{
+		Time: time.Date(2024, 12, 1, 10, 30, 0, 123456000, time.UTC),
+		ID:   uuid.New(),
+	}
+
+	cursor, err := DecodeCursor(expected.Encode())
+	if err != nil {
+		t.Fatalf("error decoding cursor %v", err)
+	}
+
+	if !cursor.Time.Equal(expected.Time) || cursor.ID != expected.ID {
+		t.Errorf("expected %v, got %v", expected, cursor)
+	}
+}
+
+func TestRankedCursorRoundTrip(t *testing.T) {
+	expected := Cursor{Rank: 0.0625, ID: uuid.New()}
+
+	cursor, err := DecodeCursor(expected.Encode())
+	if err != nil {
+		t.Fatalf("error decoding cursor %v", err)
+	}
+
+	if cursor.Rank != expected.Rank || cursor.ID != expected.ID {
+		t.Errorf("expected %v, got %v", expected, cursor)
+	}
+}
+
+func TestDecodeCursorFail(t *testing.T) {
+	for _, s := range []string{"not a cursor!", "bm9wZQ"} {
+		if _, err := DecodeCursor(s); err != ErrInvalidCursor {
+			t.Errorf("expected ErrInvalidCursor for %q, got %v", s, err)
+		}
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	page, err := ParseQuery(url.Values{})
+	if err != nil {
+		t.Fatalf("error parsing empty query %v", err)
+	}
+	if page.Limit != DefaultLimit || page.Cursor != nil {
+		t.Errorf("unexpected default page %v", page)
+	}
+
+	cursor := Cursor{Time: time.Now().UTC(), ID: uuid.New()}
+	page, err = ParseQuery(url.Values{"limit": {"5"}, "cursor": {cursor.Encode()}})
+	if err != nil {
+		t.Fatalf("error parsing query %v", err)
+	}
+	if page.Limit != 5 || page.Cursor == nil || page.Cursor.ID != cursor.ID {
+		t.Errorf("unexpected page %v", page)
+	}
+
+	for _, limit := range []string{"0", "-1", "abc", "1000"} {
+		if _, err := ParseQuery(url.Values{"limit": {limit}}); err != ErrInvalidLimit {
+			t.Errorf("expected ErrInvalidLimit for %v, got %v", limit, err)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package search
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/geophpherie/boot-dev-chirpy-v2/internal/entities"
+)
+
+const dateLayout = "2006-01-02"
+
+var ErrEmptyQuery = errors.New("search query is empty")
+var ErrInvalidDate = errors.New("dates must look like 2006-01-02")
+
+// Query is a parsed chirp search. Text keeps whatever isn't an operator,
+// including quoted phrases, OR and -exclusions, in the syntax Postgres'
+// websearch_to_tsquery understands.
+type Query struct {
+	Text  string
+	From  []string
+	Tags  []string
+	Since time.Time
+	Until time.Time
+}
+
+// Parse splits a search string into full-text terms and the operators
+// from:handle, #tag, since:date and until:date. Dates are whole UTC days
+// and until is inclusive.
+func Parse(s string) (Query, error) {
+	query := Query{}
+	text := []string{}
+
+	for _, token := range tokenize(s) {
+		lower := strings.ToLower(token)
+		switch {
+		case strings.HasPrefix(lower, "from:") && len(lower) > len("from:"):
+			query.From = append(query.From, strings.TrimPrefix(lower[len("from:"):], "@"))
+		case strings.HasPrefix(lower, "since:"):
+			since, err := time.Parse(dateLayout, lower[len("since:"):])
+			if err != nil {
+				return Query{}, ErrInvalidDate
+			}
+			query.Since = since
+		case strings.HasPrefix(lower, "until:"):
+			until, err := time.Parse(dateLayout, lower[len("until:"):])
+			if err != nil {
+				return Query{}, ErrInvalidDate
+			}
+			query.Until = until.AddDate(0, 0, 1)
+		case strings.HasPrefix(token, "#") && entities.NormalizeHashtag(token) != "":
+			query.Tags = append(query.Tags, entities.NormalizeHashtag(token))
+		default:
+			text = append(text, token)
+		}
+	}
+
+	query.Text = strings.Join(text, " ")
+	if query.Text == "" && len(query.From) == 0 && len(query.Tags) == 0 {
+		return Query{}, ErrEmptyQuery
+	}
+	return query, nil
+}
+
+// tokenize splits on whitespace but keeps double-quoted phrases, quotes
+// included, as single tokens. An unterminated quote runs to the end.
+func tokenize(s string) []string {
+	tokens := []string{}
+	var current strings.Builder
+	quoted := false
+
+	for _, r := range s {
+		switch {
+		case r == '"':
+			current.WriteRune(r)
+			quoted = !quoted
+		case !quoted && (r == ' ' || r == '\t' || r == '\n'):
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+	return tokens
+}
@@ -0,0 +1,76 @@
+package search
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	query, err := Parse(`"hello world" from:@Alice #Go since:2024-01-01 until:2024-01-31 -spam`)
+	if err != nil {
+		t.Fatalf("error parsing %v", err)
+	}
+
+	if query.Text != `"hello world" -spam` {
+		t.Errorf("unexpected text %q", query.Text)
+	}
+	if !slices.Equal(query.From, []string{"alice"}) {
+		t.Errorf("unexpected from %v", query.From)
+	}
+	if !slices.Equal(query.Tags, []string{"go"}) {
+		t.Errorf("unexpected tags %v", query.Tags)
+	}
+	if !query.Since.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected since %v", query.Since)
+	}
+	if !query.Until.Equal(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("until should include the whole day, got %v", query.Until)
+	}
+}
+
+func TestParseOperatorsOnly(t *testing.T) {
+	query, err := Parse("from:bob")
+	if err != nil {
+		t.Fatalf("error parsing %v", err)
+	}
+	if query.Text != "" || !slices.Equal(query.From, []string{"bob"}) {
+		t.Errorf("unexpected query %+v", query)
+	}
+}
+
+func TestParseUnterminatedQuote(t *testing.T) {
+	query, err := Parse(`"open phrase here`)
+	if err != nil {
+		t.Fatalf("error parsing %v", err)
+	}
+	if query.Text != `"open phrase here` {
+		t.Errorf("unexpected text %q", query.Text)
+	}
+}
+
+func TestParseFail(t *testing.T) {
+	cases := map[string]error{
+		"":                 ErrEmptyQuery,
+		"   ":              ErrEmptyQuery,
+		"since:2024-01-01": ErrEmptyQuery,
+		"cats since:jan":   ErrInvalidDate,
+		"cats until:2024":  ErrInvalidDate,
+	}
+
+	for s, expected := range cases {
+		if _, err := Parse(s); err != expected {
+			t.Errorf("Parse(%q): expected %v, got %v", s, expected, err)
+		}
+	}
+}
+
+func TestParseNotAHashtag(t *testing.T) {
+	query, err := Parse("#1 fans")
+	if err != nil {
+		t.Fatalf("error parsing %v", err)
+	}
+	if query.Text != "#1 fans" || len(query.Tags) != 0 {
+		t.Errorf("unexpected query %+v", query)
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"database/sql"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strconv"
+	"time"
 
 	"github.com/geophpherie/boot-dev-chirpy-v2/internal/database"
 	"github.com/joho/godotenv"
@@ -21,11 +24,71 @@ func main() {
 
 	dbQueries := database.New(db)
 
+	exportDir := os.Getenv("EXPORT_DIR")
+	if exportDir == "" {
+		exportDir = filepath.Join(os.TempDir(), "chirpy-exports")
+	}
+
+	mediaDir := os.Getenv("MEDIA_DIR")
+	if mediaDir == "" {
+		mediaDir = filepath.Join(os.TempDir(), "chirpy-media")
+	}
+
+	fanOutThreshold, err := strconv.Atoi(os.Getenv("FAN_OUT_THRESHOLD"))
+	if err != nil {
+		fanOutThreshold = defaultFanOutThreshold
+	}
+
+	registrationMode, err := parseRegistrationMode(os.Getenv("REGISTRATION_MODE"))
+	if err != nil {
+		panic(err)
+	}
+
+	editWindow, err := time.ParseDuration(os.Getenv("EDIT_WINDOW"))
+	if err != nil {
+		editWindow = defaultEditWindow
+	}
+
+	maxChirpLength, err := strconv.Atoi(os.Getenv("MAX_CHIRP_LENGTH"))
+	if err != nil {
+		maxChirpLength = defaultMaxChirpLength
+	}
+
+	maxRedChirpLength, err := strconv.Atoi(os.Getenv("MAX_RED_CHIRP_LENGTH"))
+	if err != nil {
+		maxRedChirpLength = defaultMaxRedChirpLength
+	}
+
+	trashRetention, err := time.ParseDuration(os.Getenv("TRASH_RETENTION"))
+	if err != nil {
+		trashRetention = defaultTrashRetention
+	}
+
 	config := apiConfig{
-		dbQueries: *dbQueries,
-		secret:    os.Getenv("SECRET"),
+		db:                db,
+		dbQueries:         *dbQueries,
+		secret:            os.Getenv("SECRET"),
+		exportDir:         exportDir,
+		exportWake:        make(chan struct{}, 1),
+		mediaDir:          mediaDir,
+		mediaWake:         make(chan struct{}, 1),
+		fanOutThreshold:   int32(fanOutThreshold),
+		fanOutWake:        make(chan struct{}, 1),
+		registrationMode:  registrationMode,
+		editWindow:        editWindow,
+		maxChirpLength:    maxChirpLength,
+		maxRedChirpLength: maxRedChirpLength,
+		trashRetention:    trashRetention,
 	}
 
+	runPeriodically("data export worker", time.Minute, config.exportWake, config.processDataExports)
+	runPeriodically("timeline fan-out worker", 10*time.Second, config.fanOutWake, config.processFanOut)
+	runPeriodically("trends worker", 5*time.Minute, nil, config.refreshTrends)
+	runPeriodically("media worker", 30*time.Second, config.mediaWake, config.processMedia)
+	runPeriodically("scheduled chirp worker", 10*time.Second, nil, config.publishScheduledChirps)
+	runPeriodically("trash purge worker", time.Hour, nil, config.purgeTrash)
+	runPeriodically("poll worker", time.Minute, nil, config.notifyClosedPolls)
+
 	mux := http.NewServeMux()
 
 	fsHandler := config.middlewareMetricsInc(http.StripPrefix("/app", http.FileServer(http.Dir("."))))
@@ -33,13 +96,66 @@ func main() {
 
 	mux.HandleFunc("GET /admin/metrics", config.handlerMetrics)
 	mux.HandleFunc("POST /admin/reset", config.handlerReset)
+	mux.HandleFunc("GET /admin/registrations", config.handlerGetPendingRegistrations)
+	mux.HandleFunc("POST /admin/registrations/{userId}/approve", config.handlerApproveRegistration)
+	mux.HandleFunc("POST /admin/registrations/{userId}/reject", config.handlerRejectRegistration)
+	mux.HandleFunc("GET /admin/chirps/deleted", config.handlerAdminGetDeletedChirps)
+	mux.HandleFunc("GET /admin/chirps/{chirpId}", config.handlerAdminGetChirp)
+	mux.HandleFunc("PUT /admin/chirps/{chirpId}/sensitive", config.handlerAdminSetSensitive)
 
 	mux.HandleFunc("GET /api/healthz", handlerReadiness)
 
 	mux.HandleFunc("POST /api/chirps", config.handlerNewChirp)
 	mux.HandleFunc("GET /api/chirps", config.handlerGetAllChirps)
+	mux.HandleFunc("GET /api/chirps/rules", config.handlerGetChirpRules)
+	mux.HandleFunc("GET /api/chirps/scheduled", config.handlerGetScheduledChirps)
+	mux.HandleFunc("PUT /api/chirps/scheduled/{scheduledId}", config.handlerEditScheduledChirp)
+	mux.HandleFunc("POST /api/chirps/scheduled/{scheduledId}/cancel", config.handlerCancelScheduledChirp)
+	mux.HandleFunc("GET /api/chirps/trash", config.handlerGetTrash)
 	mux.HandleFunc("GET /api/chirps/{chirpId}", config.handlerGetChirp)
+	mux.HandleFunc("PUT /api/chirps/{chirpId}", config.handlerEditChirp)
+	mux.HandleFunc("GET /api/chirps/{chirpId}/history", config.handlerGetChirpHistory)
+	mux.HandleFunc("GET /api/chirps/{chirpId}/thread", config.handlerGetThread)
 	mux.HandleFunc("DELETE /api/chirps/{chirpId}", config.handlerDeleteChirp)
+	mux.HandleFunc("POST /api/chirps/{chirpId}/restore", config.handlerRestoreChirp)
+	mux.HandleFunc("POST /api/chirps/{chirpId}/pin", config.handlerPinChirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpId}/pin", config.handlerUnpinChirp)
+	mux.HandleFunc("POST /api/chirps/{chirpId}/bookmark", config.handlerBookmark)
+	mux.HandleFunc("DELETE /api/chirps/{chirpId}/bookmark", config.handlerUnbookmark)
+	mux.HandleFunc("POST /api/chirps/{chirpId}/poll/votes", config.handlerVoteInPoll)
+	mux.HandleFunc("POST /api/chirps/{chirpId}/like", config.handlerLike)
+	mux.HandleFunc("DELETE /api/chirps/{chirpId}/like", config.handlerUnlike)
+	mux.HandleFunc("GET /api/chirps/{chirpId}/likes", config.handlerGetChirpLikes)
+	mux.HandleFunc("POST /api/chirps/{chirpId}/rechirp", config.handlerRechirp)
+	mux.HandleFunc("DELETE /api/chirps/{chirpId}/rechirp", config.handlerUndoRechirp)
+
+	mux.HandleFunc("POST /api/media", config.handlerUploadMedia)
+	mux.HandleFunc("GET /api/media/{mediaId}", config.handlerGetMedia)
+	mux.HandleFunc("PUT /api/media/{mediaId}", config.handlerUpdateMedia)
+	mux.HandleFunc("GET /api/media/{mediaId}/file", config.handlerGetMediaFile)
+	mux.HandleFunc("GET /api/media/{mediaId}/thumbnail", config.handlerGetMediaThumbnail)
+
+	mux.HandleFunc("POST /api/drafts", config.handlerNewDraft)
+	mux.HandleFunc("GET /api/drafts", config.handlerGetDrafts)
+	mux.HandleFunc("GET /api/drafts/{draftId}", config.handlerGetDraft)
+	mux.HandleFunc("PUT /api/drafts/{draftId}", config.handlerUpdateDraft)
+	mux.HandleFunc("DELETE /api/drafts/{draftId}", config.handlerDeleteDraft)
+	mux.HandleFunc("POST /api/drafts/{draftId}/publish", config.handlerPublishDraft)
+
+	mux.HandleFunc("GET /api/timeline", config.handlerGetTimeline)
+
+	mux.HandleFunc("GET /api/bookmarks", config.handlerGetBookmarks)
+	mux.HandleFunc("POST /api/bookmarks/folders", config.handlerNewBookmarkFolder)
+	mux.HandleFunc("GET /api/bookmarks/folders", config.handlerGetBookmarkFolders)
+	mux.HandleFunc("PUT /api/bookmarks/folders/{folderId}", config.handlerRenameBookmarkFolder)
+	mux.HandleFunc("DELETE /api/bookmarks/folders/{folderId}", config.handlerDeleteBookmarkFolder)
+
+	mux.HandleFunc("GET /api/notifications", config.handlerGetNotifications)
+	mux.HandleFunc("GET /api/notifications/unread_count", config.handlerGetUnreadNotificationCount)
+	mux.HandleFunc("POST /api/notifications/read", config.handlerMarkNotificationsRead)
+
+	mux.HandleFunc("GET /api/hashtags/{tag}/chirps", config.handlerGetHashtagChirps)
+	mux.HandleFunc("GET /api/trends", config.handlerGetTrends)
 
 	mux.HandleFunc("POST /api/users", config.handlerNewUser)
 	mux.HandleFunc("PUT /api/users", config.handlerUpdateUser)
@@ -47,6 +163,39 @@ func main() {
 	mux.HandleFunc("POST /api/refresh", config.handlerRefresh)
 	mux.HandleFunc("POST /api/revoke", config.handlerRevoke)
 
+	mux.HandleFunc("PUT /api/users/settings", config.handlerUpdateSettings)
+	mux.HandleFunc("PUT /api/users/profile", config.handlerUpdateProfile)
+	mux.HandleFunc("PUT /api/users/pins", config.handlerReorderPins)
+	mux.HandleFunc("GET /api/users/search", config.handlerSearchUsers)
+	mux.HandleFunc("GET /api/search/chirps", config.handlerSearchChirps)
+	mux.HandleFunc("GET /api/users/directory", config.handlerGetUserDirectory)
+	mux.HandleFunc("GET /api/users/{userId}", config.handlerGetUser)
+
+	mux.HandleFunc("POST /api/users/{userId}/follow", config.handlerFollow)
+	mux.HandleFunc("DELETE /api/users/{userId}/follow", config.handlerUnfollow)
+	mux.HandleFunc("GET /api/users/{userId}/followers", config.handlerGetFollowers)
+	mux.HandleFunc("GET /api/users/{userId}/following", config.handlerGetFollowing)
+	mux.HandleFunc("GET /api/users/{userId}/likes", config.handlerGetUserLikes)
+	mux.HandleFunc("GET /api/follow_requests", config.handlerGetFollowRequests)
+	mux.HandleFunc("POST /api/follow_requests/{userId}/approve", config.handlerApproveFollowRequest)
+	mux.HandleFunc("DELETE /api/follow_requests/{userId}", config.handlerRejectFollowRequest)
+
+	mux.HandleFunc("POST /api/users/{userId}/block", config.handlerBlock)
+	mux.HandleFunc("DELETE /api/users/{userId}/block", config.handlerUnblock)
+	mux.HandleFunc("GET /api/blocks", config.handlerGetBlocks)
+	mux.HandleFunc("POST /api/users/{userId}/mute", config.handlerMute)
+	mux.HandleFunc("DELETE /api/users/{userId}/mute", config.handlerUnmute)
+	mux.HandleFunc("GET /api/mutes", config.handlerGetMutes)
+
+	mux.HandleFunc("POST /api/invites", config.handlerNewInvite)
+	mux.HandleFunc("GET /api/invites", config.handlerGetInvites)
+	mux.HandleFunc("DELETE /api/invites/{code}", config.handlerRevokeInvite)
+	mux.HandleFunc("GET /api/invites/{code}/redemptions", config.handlerGetInviteRedemptions)
+
+	mux.HandleFunc("POST /api/users/export", config.handlerNewDataExport)
+	mux.HandleFunc("GET /api/exports/{exportId}", config.handlerGetDataExport)
+	mux.HandleFunc("GET /api/exports/{exportId}/download", config.handlerDownloadDataExport)
+
 	mux.HandleFunc("POST /api/polka/webhooks", config.handlerWebhooks)
 
 	server := http.Server{
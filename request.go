@@ -0,0 +1,73 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/geophpherie/boot-dev-chirpy-v2/internal/auth"
+	"github.com/geophpherie/boot-dev-chirpy-v2/internal/pagination"
+	"github.com/google/uuid"
+)
+
+var errNotAdmin = errors.New("user is not an admin")
+
+func (cfg *apiConfig) authenticate(r *http.Request) (uuid.UUID, error) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	return auth.ValidateJWT(token, cfg.secret)
+}
+
+// authenticateAdmin is authenticate for endpoints restricted to admins.
+func (cfg *apiConfig) authenticateAdmin(r *http.Request) (uuid.UUID, error) {
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+
+	user, err := cfg.dbQueries.GetUser(r.Context(), userId)
+	if err != nil {
+		return uuid.UUID{}, err
+	}
+	if !user.IsAdmin {
+		return uuid.UUID{}, errNotAdmin
+	}
+	return userId, nil
+}
+
+// viewer identifies the caller on endpoints that also serve anonymous
+// requests. A missing token is fine, a bad one is still an error.
+func (cfg *apiConfig) viewer(r *http.Request) (uuid.NullUUID, error) {
+	if r.Header.Get("Authorization") == "" {
+		return uuid.NullUUID{}, nil
+	}
+
+	userId, err := cfg.authenticate(r)
+	if err != nil {
+		return uuid.NullUUID{}, err
+	}
+	return uuid.NullUUID{UUID: userId, Valid: true}, nil
+}
+
+// cursorArgs splits a page cursor into the nullable keyset arguments used by
+// the paginated queries; a nil cursor starts from the first page.
+func cursorArgs(cursor *pagination.Cursor) (sql.NullTime, uuid.NullUUID) {
+	if cursor == nil {
+		return sql.NullTime{}, uuid.NullUUID{}
+	}
+	return sql.NullTime{Time: cursor.Time, Valid: true}, uuid.NullUUID{UUID: cursor.ID, Valid: true}
+}
+
+// paginate trims a result fetched with one extra row down to the page limit
+// and returns the cursor for the next page, if there is one.
+func paginate[T any](items []T, limit int32, cursor func(T) pagination.Cursor) ([]T, string) {
+	if len(items) <= int(limit) {
+		return items, ""
+	}
+
+	items = items[:limit]
+	return items, cursor(items[len(items)-1]).Encode()
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 )
 
@@ -27,3 +28,25 @@ func jsonResponse(w http.ResponseWriter, code int, payload interface{}) {
 		w.Write(dat)
 	}
 }
+
+// pageResponse wraps one page of a listing along with the cursor for the
+// next page, which is also advertised in a Link header.
+func pageResponse(w http.ResponseWriter, r *http.Request, data interface{}, nextCursor string) {
+	if nextCursor != "" {
+		next := *r.URL
+		query := next.Query()
+		query.Set("cursor", nextCursor)
+		next.RawQuery = query.Encode()
+		w.Header().Add("Link", fmt.Sprintf(`<%v>; rel="next"`, next.RequestURI()))
+	}
+
+	page := struct {
+		Data       interface{} `json:"data"`
+		NextCursor string      `json:"next_cursor,omitempty"`
+	}{
+		Data:       data,
+		NextCursor: nextCursor,
+	}
+
+	jsonResponse(w, http.StatusOK, page)
+}
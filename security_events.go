@@ -0,0 +1,31 @@
+package main
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/geophpherie/boot-dev-chirpy-v2/internal/database"
+	"github.com/google/uuid"
+)
+
+const (
+	securityEventLogin          = "login"
+	securityEventLoginFailed    = "login_failed"
+	securityEventAccountUpdated = "account_updated"
+	securityEventTokenRevoked   = "token_revoked"
+	securityEventExportRequest  = "export_requested"
+)
+
+func (cfg *apiConfig) recordSecurityEvent(r *http.Request, userId uuid.UUID, event string) {
+	args := database.CreateSecurityEventParams{
+		UserID:    userId,
+		Event:     event,
+		IpAddress: r.RemoteAddr,
+		UserAgent: r.UserAgent(),
+	}
+
+	err := cfg.dbQueries.CreateSecurityEvent(r.Context(), args)
+	if err != nil {
+		log.Printf("Error recording %v event for %v :: %v", event, userId, err)
+	}
+}
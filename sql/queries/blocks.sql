@@ -0,0 +1,50 @@
+-- name: CreateBlock :exec
+INSERT INTO blocks (blocker_id, blocked_id, created_at)
+VALUES (
+	$1,
+	$2,
+	NOW()
+	)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteBlock :exec
+DELETE FROM blocks WHERE blocker_id = $1 AND blocked_id = $2;
+
+-- name: IsBlocked :one
+SELECT EXISTS (
+	SELECT 1 FROM blocks
+	WHERE (blocker_id = sqlc.arg(user_id) AND blocked_id = sqlc.arg(other_id))
+		OR (blocker_id = sqlc.arg(other_id) AND blocked_id = sqlc.arg(user_id))
+	) AS blocked;
+
+-- name: GetBlockedUsers :many
+SELECT sqlc.embed(users), b.created_at AS blocked_at
+FROM blocks b
+JOIN users ON users.id = b.blocked_id
+WHERE b.blocker_id = sqlc.arg(user_id)
+	AND (sqlc.narg(before_created_at)::timestamp IS NULL
+		OR (b.created_at, b.blocked_id) < (sqlc.narg(before_created_at), sqlc.narg(before_id)::uuid))
+ORDER BY b.created_at DESC, b.blocked_id DESC
+LIMIT sqlc.arg(max_results);
+
+-- name: CreateMute :exec
+INSERT INTO mutes (muter_id, muted_id, created_at)
+VALUES (
+	$1,
+	$2,
+	NOW()
+	)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteMute :exec
+DELETE FROM mutes WHERE muter_id = $1 AND muted_id = $2;
+
+-- name: GetMutedUsers :many
+SELECT sqlc.embed(users), m.created_at AS muted_at
+FROM mutes m
+JOIN users ON users.id = m.muted_id
+WHERE m.muter_id = sqlc.arg(user_id)
+	AND (sqlc.narg(before_created_at)::timestamp IS NULL
+		OR (m.created_at, m.muted_id) < (sqlc.narg(before_created_at), sqlc.narg(before_id)::uuid))
+ORDER BY m.created_at DESC, m.muted_id DESC
+LIMIT sqlc.arg(max_results);
@@ -0,0 +1,58 @@
+-- name: CreateBookmark :exec
+INSERT INTO bookmarks (user_id, chirp_id, folder_id, created_at)
+VALUES (
+	$1,
+	$2,
+	$3,
+	NOW()
+	)
+ON CONFLICT (user_id, chirp_id) DO UPDATE SET folder_id = EXCLUDED.folder_id;
+
+-- name: DeleteBookmark :execrows
+DELETE FROM bookmarks WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: DeleteChirpBookmarks :exec
+DELETE FROM bookmarks WHERE chirp_id = $1;
+
+-- name: GetBookmarkedChirpIds :many
+SELECT chirp_id FROM bookmarks
+WHERE user_id = sqlc.arg(user_id) AND chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[]);
+
+-- name: GetBookmarks :many
+SELECT sqlc.embed(chirps), b.folder_id, b.created_at AS bookmarked_at
+FROM bookmarks b
+JOIN chirps ON chirps.id = b.chirp_id
+WHERE b.user_id = sqlc.arg(user_id)
+	AND chirps.deleted_at IS NULL
+	AND NOT user_hidden_from(chirps.user_id, sqlc.arg(user_id)::uuid)
+	AND can_view_chirp(chirps, sqlc.arg(user_id)::uuid)
+	AND (sqlc.narg(folder_id)::uuid IS NULL OR b.folder_id = sqlc.narg(folder_id))
+	AND (sqlc.narg(before_created_at)::timestamp IS NULL
+		OR (b.created_at, b.chirp_id) < (sqlc.narg(before_created_at), sqlc.narg(before_id)::uuid))
+ORDER BY b.created_at DESC, b.chirp_id DESC
+LIMIT sqlc.arg(max_results);
+
+-- name: CreateBookmarkFolder :one
+INSERT INTO bookmark_folders (id, created_at, updated_at, user_id, name)
+VALUES (
+	gen_random_uuid(),
+	NOW(),
+	NOW(),
+	$1,
+	$2
+	)
+RETURNING *;
+
+-- name: GetBookmarkFolder :one
+SELECT * FROM bookmark_folders WHERE id = $1 AND user_id = $2;
+
+-- name: GetBookmarkFolders :many
+SELECT * FROM bookmark_folders WHERE user_id = $1 ORDER BY name;
+
+-- name: RenameBookmarkFolder :one
+UPDATE bookmark_folders SET name = $3, updated_at = NOW()
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: DeleteBookmarkFolder :execrows
+DELETE FROM bookmark_folders WHERE id = $1 AND user_id = $2;
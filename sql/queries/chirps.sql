@@ -1,25 +1,188 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
-VALUES(
-	gen_random_uuid(),
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, in_reply_to, conversation_id, quote_of, visibility, content_warning, sensitive, reply_path)
+SELECT
+	new_chirp.id,
 	NOW(),
 	NOW(),
-	$1,
-	$2
-	)
+	sqlc.arg(body)::text,
+	sqlc.arg(user_id)::uuid,
+	sqlc.narg(in_reply_to)::uuid,
+	COALESCE(sqlc.narg(conversation_id)::uuid, new_chirp.id),
+	sqlc.narg(quote_of)::uuid,
+	sqlc.arg(visibility)::text,
+	sqlc.narg(content_warning)::text,
+	sqlc.arg(sensitive)::boolean,
+	COALESCE((
+		SELECT parent.reply_path || parent.id FROM chirps parent
+		WHERE parent.id = sqlc.narg(in_reply_to)::uuid
+	), '{}')
+FROM (SELECT gen_random_uuid() AS id) new_chirp
+RETURNING *;
+
+-- name: CreateRechirp :one
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, conversation_id, rechirp_of)
+SELECT
+	new_chirp.id,
+	NOW(),
+	NOW(),
+	'',
+	sqlc.arg(user_id)::uuid,
+	new_chirp.id,
+	sqlc.arg(rechirp_of)::uuid
+FROM (SELECT gen_random_uuid() AS id) new_chirp
+ON CONFLICT (user_id, rechirp_of) WHERE rechirp_of IS NOT NULL DO NOTHING
 RETURNING *;
 
--- name: GetAllChirps :many
-SELECT * FROM chirps ORDER BY created_at ASC;
+-- name: GetRechirp :one
+SELECT * FROM chirps WHERE user_id = $1 AND rechirp_of = $2;
 
--- name: GetAllChirpsByUserId :many
-SELECT * FROM chirps WHERE user_id = $1 ORDER BY created_at ASC;
+-- name: DeleteRechirp :execrows
+DELETE FROM chirps WHERE user_id = $1 AND rechirp_of = $2;
+
+-- name: GetEmbeddedChirps :many
+SELECT sqlc.embed(c), (user_hidden_from(c.user_id, sqlc.narg(viewer_id)::uuid)
+		OR NOT can_view_chirp(c, sqlc.narg(viewer_id)::uuid))::boolean AS hidden
+FROM chirps c
+WHERE c.id = ANY(sqlc.arg(ids)::uuid[]);
 
 -- name: GetChirp :one
 SELECT * FROM chirps WHERE id = $1;
 
+-- name: GetChirpForViewer :one
+SELECT * FROM chirps
+WHERE id = sqlc.arg(id)
+	AND deleted_at IS NULL
+	AND NOT user_hidden_from(user_id, sqlc.narg(viewer_id)::uuid)
+	AND can_view_chirp(chirps, sqlc.narg(viewer_id)::uuid);
+
 -- name: DeleteChirp :exec
 DELETE FROM chirps WHERE id = $1 AND user_id = $2;
 
 -- name: DeleteAllChirps :exec
 DELETE FROM chirps;
+
+-- name: GetChirpsByUserIdAfter :many
+SELECT * FROM chirps
+WHERE user_id = sqlc.arg(user_id)
+	AND deleted_at IS NULL
+	AND (created_at, id) > (sqlc.arg(after_created_at)::timestamp, sqlc.arg(after_id)::uuid)
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg(max_results);
+
+-- name: GetChirpsForFanOut :many
+SELECT * FROM chirps
+WHERE fanned_out_at IS NULL
+ORDER BY created_at ASC
+LIMIT $1
+FOR UPDATE SKIP LOCKED;
+
+-- name: MarkChirpsFannedOut :exec
+UPDATE chirps SET fanned_out_at = NOW() WHERE id = ANY(sqlc.arg(ids)::uuid[]);
+
+-- name: GetChirpForUpdate :one
+SELECT * FROM chirps WHERE id = $1 FOR UPDATE;
+
+-- name: EditChirp :one
+UPDATE chirps SET body = $2, updated_at = NOW(), edited_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: CreateChirpRevision :exec
+INSERT INTO chirp_revisions (id, chirp_id, body, created_at)
+VALUES (
+	gen_random_uuid(),
+	$1,
+	$2,
+	$3
+	);
+
+-- name: GetChirpRevisions :many
+SELECT * FROM chirp_revisions WHERE chirp_id = $1 ORDER BY created_at DESC;
+
+-- name: TombstoneChirp :exec
+UPDATE chirps SET body = '', updated_at = NOW(), deleted_at = COALESCE(deleted_at, NOW()), purged_at = NOW() WHERE id = $1;
+
+-- name: SetChirpSensitiveForced :one
+UPDATE chirps SET sensitive_forced = $2 WHERE id = $1
+RETURNING *;
+
+-- name: SoftDeleteChirp :exec
+UPDATE chirps SET deleted_at = NOW() WHERE id = $1;
+
+-- name: RestoreChirp :one
+UPDATE chirps SET deleted_at = NULL, fanned_out_at = NULL
+WHERE id = sqlc.arg(id)
+	AND user_id = sqlc.arg(user_id)
+	AND deleted_at > sqlc.arg(deleted_after)::timestamp
+	AND purged_at IS NULL
+RETURNING *;
+
+-- name: GetTrashedChirps :many
+SELECT * FROM chirps
+WHERE user_id = sqlc.arg(user_id)
+	AND deleted_at > sqlc.arg(deleted_after)::timestamp
+	AND purged_at IS NULL
+	AND (sqlc.narg(before_deleted_at)::timestamp IS NULL
+		OR (deleted_at, id) < (sqlc.narg(before_deleted_at), sqlc.narg(before_id)::uuid))
+ORDER BY deleted_at DESC, id DESC
+LIMIT sqlc.arg(max_results);
+
+-- name: GetDeletedChirps :many
+SELECT * FROM chirps
+WHERE deleted_at IS NOT NULL
+	AND (sqlc.narg(before_deleted_at)::timestamp IS NULL
+		OR (deleted_at, id) < (sqlc.narg(before_deleted_at), sqlc.narg(before_id)::uuid))
+ORDER BY deleted_at DESC, id DESC
+LIMIT sqlc.arg(max_results);
+
+-- name: DeleteExpiredChirps :execrows
+DELETE FROM chirps
+WHERE deleted_at < sqlc.arg(deleted_before)::timestamp
+	AND purged_at IS NULL
+	AND reply_count = 0;
+
+-- name: GetExpiredChirpIds :many
+SELECT id FROM chirps
+WHERE deleted_at < sqlc.arg(deleted_before)::timestamp
+	AND purged_at IS NULL
+ORDER BY deleted_at ASC
+LIMIT sqlc.arg(max_results);
+
+-- name: DeleteChirpRevisions :exec
+DELETE FROM chirp_revisions WHERE chirp_id = $1;
+
+-- name: GetThreadChirp :one
+SELECT sqlc.embed(c), (user_hidden_from(c.user_id, sqlc.narg(viewer_id)::uuid)
+		OR NOT can_view_chirp(c, sqlc.narg(viewer_id)::uuid))::boolean AS hidden
+FROM chirps c
+WHERE c.id = sqlc.arg(id);
+
+-- name: GetChirpAncestors :many
+WITH RECURSIVE ancestors AS (
+	SELECT parent.id, parent.in_reply_to, 1 AS depth
+	FROM chirps child
+	JOIN chirps parent ON parent.id = child.in_reply_to
+	WHERE child.id = sqlc.arg(id)
+	UNION ALL
+	SELECT parent.id, parent.in_reply_to, a.depth + 1
+	FROM ancestors a
+	JOIN chirps parent ON parent.id = a.in_reply_to
+)
+SELECT sqlc.embed(c), (user_hidden_from(c.user_id, sqlc.narg(viewer_id)::uuid)
+		OR NOT can_view_chirp(c, sqlc.narg(viewer_id)::uuid))::boolean AS hidden
+FROM ancestors a
+JOIN chirps c ON c.id = a.id
+ORDER BY a.depth DESC;
+
+-- name: GetChirpDescendants :many
+SELECT sqlc.embed(c), (cardinality(c.reply_path) - cardinality(focus.reply_path))::int AS depth,
+	(user_hidden_from(c.user_id, sqlc.narg(viewer_id)::uuid)
+		OR NOT can_view_chirp(c, sqlc.narg(viewer_id)::uuid))::boolean AS hidden
+FROM chirps focus
+JOIN chirps c ON c.conversation_id = focus.conversation_id
+WHERE focus.id = sqlc.arg(id)::uuid
+	AND focus.id = ANY(c.reply_path)
+	AND (sqlc.narg(after_created_at)::timestamp IS NULL
+		OR (c.created_at, c.id) > (sqlc.narg(after_created_at)::timestamp, sqlc.narg(after_id)::uuid))
+ORDER BY c.created_at ASC, c.id ASC
+LIMIT sqlc.arg(max_results);
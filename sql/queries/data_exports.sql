@@ -0,0 +1,46 @@
+-- name: CreateDataExport :one
+INSERT INTO data_exports (id, created_at, updated_at, user_id)
+VALUES (
+	gen_random_uuid(),
+	NOW(),
+	NOW(),
+	$1
+	)
+RETURNING *;
+
+-- name: GetDataExport :one
+SELECT * FROM data_exports WHERE id = $1 AND user_id = $2;
+
+-- name: GetActiveDataExport :one
+SELECT * FROM data_exports
+WHERE user_id = $1 AND status IN ('pending', 'processing')
+ORDER BY created_at DESC
+LIMIT 1;
+
+-- name: GetDataExportByToken :one
+SELECT * FROM data_exports
+WHERE id = $1 AND download_token = $2 AND status = 'complete' AND expires_at > NOW();
+
+-- name: ClaimDataExport :one
+UPDATE data_exports SET status = 'processing', claimed_at = NOW(), updated_at = NOW()
+WHERE id = (
+	SELECT id FROM data_exports
+	WHERE status = 'pending'
+		OR (status = 'processing'
+			AND (claimed_at IS NULL OR claimed_at < NOW() - make_interval(secs => sqlc.arg(claim_timeout_seconds)::double precision)))
+	ORDER BY created_at ASC
+	LIMIT 1
+	FOR UPDATE SKIP LOCKED
+	)
+RETURNING *;
+
+-- name: CompleteDataExport :exec
+UPDATE data_exports
+SET status = 'complete', updated_at = NOW(), file_path = $2, download_token = $3, expires_at = $4
+WHERE id = $1;
+
+-- name: FailDataExport :exec
+UPDATE data_exports SET status = 'failed', updated_at = NOW() WHERE id = $1;
+
+-- name: DeleteExpiredDataExports :many
+DELETE FROM data_exports WHERE expires_at < NOW() RETURNING file_path;
@@ -0,0 +1,39 @@
+-- name: CreateDraft :one
+INSERT INTO drafts (id, created_at, updated_at, user_id, body, in_reply_to, quote_of, media_ids, visibility, content_warning, sensitive)
+VALUES (
+	gen_random_uuid(),
+	NOW(),
+	NOW(),
+	$1,
+	$2,
+	$3,
+	$4,
+	$5,
+	$6,
+	$7,
+	$8
+	)
+RETURNING *;
+
+-- name: GetDraft :one
+SELECT * FROM drafts WHERE id = $1 AND user_id = $2;
+
+-- name: GetDrafts :many
+SELECT * FROM drafts
+WHERE user_id = sqlc.arg(user_id)
+	AND (sqlc.narg(before_updated_at)::timestamp IS NULL
+		OR (updated_at, id) < (sqlc.narg(before_updated_at), sqlc.narg(before_id)::uuid))
+ORDER BY updated_at DESC, id DESC
+LIMIT sqlc.arg(max_results);
+
+-- name: UpdateDraft :one
+UPDATE drafts
+SET body = $4, in_reply_to = $5, quote_of = $6, media_ids = $7, visibility = $8, content_warning = $9, sensitive = $10, version = version + 1, updated_at = NOW()
+WHERE id = $1 AND user_id = $2 AND version = $3
+RETURNING *;
+
+-- name: DeleteDraft :execrows
+DELETE FROM drafts WHERE id = $1 AND user_id = $2;
+
+-- name: DeleteDraftVersion :execrows
+DELETE FROM drafts WHERE id = $1 AND user_id = $2 AND version = $3;
@@ -0,0 +1,62 @@
+-- name: CreateFollow :one
+INSERT INTO follows (follower_id, followee_id, created_at, updated_at, status)
+VALUES (
+	$1,
+	$2,
+	NOW(),
+	NOW(),
+	$3
+	)
+ON CONFLICT (follower_id, followee_id) DO NOTHING
+RETURNING *;
+
+-- name: GetFollow :one
+SELECT * FROM follows WHERE follower_id = $1 AND followee_id = $2;
+
+-- name: DeleteFollow :execrows
+DELETE FROM follows WHERE follower_id = $1 AND followee_id = $2;
+
+-- name: DeleteFollowRequest :execrows
+DELETE FROM follows WHERE follower_id = $1 AND followee_id = $2 AND status = 'pending';
+
+-- name: AcceptFollow :execrows
+UPDATE follows SET status = 'accepted', updated_at = NOW()
+WHERE follower_id = $1 AND followee_id = $2 AND status = 'pending';
+
+-- name: GetFollowers :many
+SELECT sqlc.embed(users), f.created_at AS followed_at
+FROM follows f
+JOIN users ON users.id = f.follower_id
+WHERE f.followee_id = sqlc.arg(user_id)
+	AND f.status = 'accepted'
+	AND (sqlc.narg(before_created_at)::timestamp IS NULL
+		OR (f.created_at, f.follower_id) < (sqlc.narg(before_created_at), sqlc.narg(before_id)::uuid))
+ORDER BY f.created_at DESC, f.follower_id DESC
+LIMIT sqlc.arg(max_results);
+
+-- name: GetFollowing :many
+SELECT sqlc.embed(users), f.created_at AS followed_at
+FROM follows f
+JOIN users ON users.id = f.followee_id
+WHERE f.follower_id = sqlc.arg(user_id)
+	AND f.status = 'accepted'
+	AND (sqlc.narg(before_created_at)::timestamp IS NULL
+		OR (f.created_at, f.followee_id) < (sqlc.narg(before_created_at), sqlc.narg(before_id)::uuid))
+ORDER BY f.created_at DESC, f.followee_id DESC
+LIMIT sqlc.arg(max_results);
+
+-- name: GetFollowRequests :many
+SELECT sqlc.embed(users), f.created_at AS followed_at
+FROM follows f
+JOIN users ON users.id = f.follower_id
+WHERE f.followee_id = sqlc.arg(user_id)
+	AND f.status = 'pending'
+	AND (sqlc.narg(before_created_at)::timestamp IS NULL
+		OR (f.created_at, f.follower_id) < (sqlc.narg(before_created_at), sqlc.narg(before_id)::uuid))
+ORDER BY f.created_at DESC, f.follower_id DESC
+LIMIT sqlc.arg(max_results);
+
+-- name: DeleteFollowsBetween :exec
+DELETE FROM follows
+WHERE (follower_id = sqlc.arg(user_id) AND followee_id = sqlc.arg(other_id))
+	OR (follower_id = sqlc.arg(other_id) AND followee_id = sqlc.arg(user_id));
@@ -0,0 +1,66 @@
+-- name: UpsertHashtag :one
+INSERT INTO hashtags (id, tag, created_at)
+VALUES (
+	gen_random_uuid(),
+	$1,
+	NOW()
+	)
+ON CONFLICT (tag) DO UPDATE SET tag = EXCLUDED.tag
+RETURNING *;
+
+-- name: AddChirpHashtag :exec
+INSERT INTO chirp_hashtags (chirp_id, hashtag_id, created_at)
+VALUES (
+	$1,
+	$2,
+	$3
+	)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteChirpHashtags :exec
+DELETE FROM chirp_hashtags WHERE chirp_id = $1;
+
+-- name: GetChirpsByHashtag :many
+SELECT c.* FROM chirp_hashtags ch
+JOIN hashtags h ON h.id = ch.hashtag_id
+JOIN chirps c ON c.id = ch.chirp_id
+WHERE h.tag = sqlc.arg(tag)
+	AND c.deleted_at IS NULL
+	AND NOT user_hidden_from(c.user_id, sqlc.narg(viewer_id)::uuid)
+	AND can_list_chirp(c, sqlc.narg(viewer_id)::uuid)
+	AND (sqlc.narg(before_created_at)::timestamp IS NULL
+		OR (ch.created_at, ch.chirp_id) < (sqlc.narg(before_created_at), sqlc.narg(before_id)::uuid))
+ORDER BY ch.created_at DESC, ch.chirp_id DESC
+LIMIT sqlc.arg(max_results);
+
+-- name: DeleteTrends :exec
+DELETE FROM trends;
+
+-- name: ComputeTrends :exec
+INSERT INTO trends (hashtag_id, score, chirp_count, computed_at)
+SELECT stats.hashtag_id, stats.score, stats.chirp_count, NOW()
+FROM (
+	SELECT
+		ch.hashtag_id,
+		SUM(exp(-extract(epoch FROM NOW() - ch.created_at) / sqlc.arg(half_life_seconds)::double precision))
+			FILTER (WHERE ch.created_at > NOW() - make_interval(secs => sqlc.arg(window_seconds)::double precision))
+			/ (1 + COUNT(*) FILTER (WHERE ch.created_at <= NOW() - make_interval(secs => sqlc.arg(window_seconds)::double precision))
+				/ sqlc.arg(baseline_windows)::double precision) AS score,
+		COUNT(*) FILTER (WHERE ch.created_at > NOW() - make_interval(secs => sqlc.arg(window_seconds)::double precision)) AS chirp_count
+	FROM chirp_hashtags ch
+	JOIN chirps c ON c.id = ch.chirp_id
+	WHERE ch.created_at > NOW() - make_interval(secs => sqlc.arg(window_seconds)::double precision * (1 + sqlc.arg(baseline_windows)::double precision))
+		AND c.deleted_at IS NULL
+		AND c.visibility = 'public'
+	GROUP BY ch.hashtag_id
+) stats
+WHERE stats.chirp_count >= sqlc.arg(min_chirps)::int
+ORDER BY stats.score DESC
+LIMIT sqlc.arg(max_results);
+
+-- name: GetTrends :many
+SELECT h.tag, t.score, t.chirp_count, t.computed_at
+FROM trends t
+JOIN hashtags h ON h.id = t.hashtag_id
+ORDER BY t.score DESC
+LIMIT sqlc.arg(max_results);
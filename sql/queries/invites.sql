@@ -0,0 +1,43 @@
+-- name: CreateInvite :one
+INSERT INTO invites (code, created_at, created_by, max_uses, expires_at)
+VALUES (
+	$1,
+	NOW(),
+	$2,
+	$3,
+	$4
+	)
+RETURNING *;
+
+-- name: GetInvitesByCreator :many
+SELECT * FROM invites WHERE created_by = $1 ORDER BY created_at DESC;
+
+-- name: GetInvite :one
+SELECT * FROM invites WHERE code = $1;
+
+-- name: RedeemInvite :one
+UPDATE invites SET uses = uses + 1
+WHERE code = $1
+	AND uses < max_uses
+	AND (expires_at IS NULL OR expires_at > NOW())
+RETURNING *;
+
+-- name: RevokeInvite :execrows
+UPDATE invites SET expires_at = NOW()
+WHERE code = $1 AND created_by = $2 AND (expires_at IS NULL OR expires_at > NOW());
+
+-- name: CreateInviteRedemption :exec
+INSERT INTO invite_redemptions (id, invite_code, user_id, redeemed_at)
+VALUES (
+	gen_random_uuid(),
+	$1,
+	$2,
+	NOW()
+	);
+
+-- name: GetInviteRedemptions :many
+SELECT sqlc.embed(users), r.redeemed_at
+FROM invite_redemptions r
+JOIN users ON users.id = r.user_id
+WHERE r.invite_code = $1
+ORDER BY r.redeemed_at ASC;
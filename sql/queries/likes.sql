@@ -0,0 +1,40 @@
+-- name: CreateLike :execrows
+INSERT INTO likes (user_id, chirp_id, created_at)
+VALUES (
+	$1,
+	$2,
+	NOW()
+	)
+ON CONFLICT (user_id, chirp_id) DO NOTHING;
+
+-- name: DeleteLike :execrows
+DELETE FROM likes WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: GetLikedChirpIds :many
+SELECT chirp_id FROM likes
+WHERE user_id = sqlc.arg(user_id) AND chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[]);
+
+-- name: GetChirpLikers :many
+SELECT sqlc.embed(users), l.created_at AS liked_at
+FROM likes l
+JOIN users ON users.id = l.user_id
+WHERE l.chirp_id = sqlc.arg(chirp_id)
+	AND NOT user_hidden_from(l.user_id, sqlc.narg(viewer_id)::uuid)
+	AND (sqlc.narg(before_created_at)::timestamp IS NULL
+		OR (l.created_at, l.user_id) < (sqlc.narg(before_created_at), sqlc.narg(before_id)::uuid))
+ORDER BY l.created_at DESC, l.user_id DESC
+LIMIT sqlc.arg(max_results);
+
+-- name: GetLikedChirps :many
+SELECT sqlc.embed(chirps), l.created_at AS liked_at
+FROM likes l
+JOIN chirps ON chirps.id = l.chirp_id
+WHERE l.user_id = sqlc.arg(user_id)
+	AND NOT user_hidden_from(l.user_id, sqlc.narg(viewer_id)::uuid)
+	AND chirps.deleted_at IS NULL
+	AND NOT user_hidden_from(chirps.user_id, sqlc.narg(viewer_id)::uuid)
+	AND can_list_chirp(chirps, sqlc.narg(viewer_id)::uuid)
+	AND (sqlc.narg(before_created_at)::timestamp IS NULL
+		OR (l.created_at, l.chirp_id) < (sqlc.narg(before_created_at), sqlc.narg(before_id)::uuid))
+ORDER BY l.created_at DESC, l.chirp_id DESC
+LIMIT sqlc.arg(max_results);
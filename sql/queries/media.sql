@@ -0,0 +1,81 @@
+-- name: CreateMedia :one
+INSERT INTO media_attachments (id, created_at, updated_at, user_id, kind, content_type, file_path, alt_text)
+VALUES (
+	gen_random_uuid(),
+	NOW(),
+	NOW(),
+	$1,
+	$2,
+	$3,
+	$4,
+	$5
+	)
+RETURNING *;
+
+-- name: GetMedia :one
+SELECT * FROM media_attachments WHERE id = $1;
+
+-- name: UpdateMediaAltText :one
+UPDATE media_attachments SET alt_text = $3, updated_at = NOW()
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: ClaimMedia :one
+UPDATE media_attachments SET claimed_at = NOW(), updated_at = NOW()
+WHERE id = (
+	SELECT id FROM media_attachments
+	WHERE status = 'processing'
+		AND (claimed_at IS NULL OR claimed_at < NOW() - make_interval(secs => sqlc.arg(claim_timeout_seconds)::double precision))
+	ORDER BY created_at ASC
+	LIMIT 1
+	FOR UPDATE SKIP LOCKED
+	)
+RETURNING *;
+
+-- name: CompleteMedia :exec
+UPDATE media_attachments
+SET status = 'ready', updated_at = NOW(), width = $2, height = $3, duration_ms = $4, blurhash = $5, thumbnail_path = $6
+WHERE id = $1;
+
+-- name: FailMedia :exec
+UPDATE media_attachments SET status = 'failed', updated_at = NOW() WHERE id = $1;
+
+-- name: AttachMedia :many
+UPDATE media_attachments
+SET chirp_id = sqlc.arg(chirp_id)::uuid,
+	position = array_position(sqlc.arg(ids)::uuid[], id)::int,
+	attached_at = NOW(),
+	updated_at = NOW()
+WHERE id = ANY(sqlc.arg(ids)::uuid[])
+	AND user_id = sqlc.arg(user_id)
+	AND status = 'ready'
+	AND attached_at IS NULL
+RETURNING kind;
+
+-- name: DetachChirpMedia :exec
+UPDATE media_attachments SET chirp_id = NULL, updated_at = NOW() WHERE chirp_id = sqlc.arg(chirp_id)::uuid;
+
+-- name: GetChirpMedia :many
+SELECT * FROM media_attachments
+WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+ORDER BY chirp_id, position;
+
+-- name: CountSchedulableMedia :one
+SELECT count(*) FROM media_attachments
+WHERE id = ANY(sqlc.arg(ids)::uuid[])
+	AND user_id = sqlc.arg(user_id)
+	AND status <> 'failed'
+	AND attached_at IS NULL;
+
+-- name: DeleteOrphanedMedia :many
+DELETE FROM media_attachments m
+WHERE m.chirp_id IS NULL
+	AND (m.attached_at IS NOT NULL OR m.created_at < sqlc.arg(uploaded_before)::timestamp)
+	AND NOT EXISTS (
+		SELECT 1 FROM scheduled_chirps s
+		WHERE s.status = 'scheduled' AND s.user_id = m.user_id AND m.id = ANY(s.media_ids)
+	)
+	AND NOT EXISTS (
+		SELECT 1 FROM drafts d WHERE d.user_id = m.user_id AND m.id = ANY(d.media_ids)
+	)
+RETURNING m.file_path, m.thumbnail_path;
@@ -0,0 +1,28 @@
+-- name: ResolveMentionHandles :many
+SELECT u.id, u.handle FROM users u
+WHERE u.handle = ANY(sqlc.arg(handles)::text[])
+	AND u.status = 'active'
+	AND NOT EXISTS (
+		SELECT 1 FROM blocks b
+		WHERE (b.blocker_id = u.id AND b.blocked_id = sqlc.arg(author_id))
+			OR (b.blocker_id = sqlc.arg(author_id) AND b.blocked_id = u.id)
+	);
+
+-- name: AddChirpMention :exec
+INSERT INTO chirp_mentions (chirp_id, user_id, start_offset, end_offset)
+VALUES (
+	$1,
+	$2,
+	$3,
+	$4
+	);
+
+-- name: DeleteChirpMentions :exec
+DELETE FROM chirp_mentions WHERE chirp_id = $1;
+
+-- name: GetChirpMentions :many
+SELECT cm.chirp_id, cm.user_id, u.handle, cm.start_offset, cm.end_offset
+FROM chirp_mentions cm
+JOIN users u ON u.id = cm.user_id
+WHERE cm.chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+ORDER BY cm.chirp_id, cm.start_offset;
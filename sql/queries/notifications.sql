@@ -0,0 +1,43 @@
+-- name: CreateMentionNotifications :exec
+INSERT INTO notifications (id, user_id, actor_id, type, chirp_id, created_at)
+SELECT gen_random_uuid(), cm.user_id, c.user_id, 'mention', c.id, NOW()
+FROM chirp_mentions cm
+JOIN chirps c ON c.id = cm.chirp_id
+WHERE cm.chirp_id = sqlc.arg(chirp_id)
+	AND cm.user_id <> c.user_id
+	AND NOT user_hidden_from(c.user_id, cm.user_id)
+ON CONFLICT DO NOTHING;
+
+-- name: GetNotifications :many
+SELECT sqlc.embed(n), sqlc.embed(users)
+FROM notifications n
+JOIN users ON users.id = n.actor_id
+LEFT JOIN chirps c ON c.id = n.chirp_id
+WHERE n.user_id = sqlc.arg(user_id)
+	AND NOT user_hidden_from(n.actor_id, n.user_id)
+	AND (n.chirp_id IS NULL OR (c.deleted_at IS NULL AND can_view_chirp(c, n.user_id)))
+	AND (sqlc.narg(before_created_at)::timestamp IS NULL
+		OR (n.created_at, n.id) < (sqlc.narg(before_created_at), sqlc.narg(before_id)::uuid))
+ORDER BY n.created_at DESC, n.id DESC
+LIMIT sqlc.arg(max_results);
+
+-- name: MarkNotificationsRead :exec
+UPDATE notifications SET read_at = NOW()
+WHERE user_id = $1 AND read_at IS NULL;
+
+-- name: CountUnreadNotifications :one
+SELECT count(*)
+FROM notifications n
+JOIN users ON users.id = n.actor_id
+LEFT JOIN chirps c ON c.id = n.chirp_id
+WHERE n.user_id = sqlc.arg(user_id)
+	AND n.read_at IS NULL
+	AND NOT user_hidden_from(n.actor_id, n.user_id)
+	AND (n.chirp_id IS NULL OR (c.deleted_at IS NULL AND can_view_chirp(c, n.user_id)));
+
+-- name: CreatePollClosedNotification :exec
+INSERT INTO notifications (id, user_id, actor_id, type, chirp_id, created_at)
+SELECT gen_random_uuid(), c.user_id, c.user_id, 'poll_closed', c.id, NOW()
+FROM chirps c
+WHERE c.id = $1 AND c.deleted_at IS NULL
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,32 @@
+-- name: PinChirp :exec
+INSERT INTO pinned_chirps (user_id, chirp_id, position, created_at)
+SELECT sqlc.arg(user_id)::uuid, sqlc.arg(chirp_id)::uuid, COALESCE(MAX(position), 0) + 1, NOW()
+FROM pinned_chirps
+WHERE user_id = sqlc.arg(user_id)
+ON CONFLICT (user_id, chirp_id) DO NOTHING;
+
+-- name: UnpinChirp :execrows
+DELETE FROM pinned_chirps WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: DeleteChirpPins :exec
+DELETE FROM pinned_chirps WHERE chirp_id = $1;
+
+-- name: GetPinnedChirpIds :many
+SELECT chirp_id FROM pinned_chirps
+WHERE user_id = $1
+ORDER BY position, created_at;
+
+-- name: ReorderPinnedChirps :exec
+UPDATE pinned_chirps
+SET position = array_position(sqlc.arg(chirp_ids)::uuid[], chirp_id)
+WHERE user_id = sqlc.arg(user_id);
+
+-- name: GetPinnedChirps :many
+SELECT sqlc.embed(chirps)
+FROM pinned_chirps p
+JOIN chirps ON chirps.id = p.chirp_id
+WHERE p.user_id = sqlc.arg(user_id)
+	AND chirps.deleted_at IS NULL
+	AND NOT user_hidden_from(chirps.user_id, sqlc.narg(viewer_id)::uuid)
+	AND can_list_chirp(chirps, sqlc.narg(viewer_id)::uuid)
+ORDER BY p.position, p.created_at;
@@ -0,0 +1,48 @@
+-- name: CreatePoll :exec
+INSERT INTO polls (chirp_id, created_at, multiple_choice, closes_at)
+VALUES (
+	$1,
+	NOW(),
+	$2,
+	$3
+	);
+
+-- name: CreatePollOption :exec
+INSERT INTO poll_options (chirp_id, position, body)
+VALUES (
+	$1,
+	$2,
+	$3
+	);
+
+-- name: GetPoll :one
+SELECT * FROM polls WHERE chirp_id = $1;
+
+-- name: GetPolls :many
+SELECT * FROM polls WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[]);
+
+-- name: GetPollOptions :many
+SELECT * FROM poll_options
+WHERE chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[])
+ORDER BY chirp_id, position;
+
+-- name: GetPollVotes :many
+SELECT * FROM poll_votes
+WHERE user_id = sqlc.arg(user_id) AND chirp_id = ANY(sqlc.arg(chirp_ids)::uuid[]);
+
+-- name: CreatePollVote :execrows
+INSERT INTO poll_votes (chirp_id, user_id, choices, created_at)
+SELECT chirp_id, sqlc.arg(user_id)::uuid, sqlc.arg(choices)::int[], NOW()
+FROM polls
+WHERE chirp_id = sqlc.arg(chirp_id) AND closes_at > NOW()
+ON CONFLICT (chirp_id, user_id) DO NOTHING;
+
+-- name: ClaimClosedPoll :one
+SELECT * FROM polls
+WHERE closes_at <= NOW() AND notified_at IS NULL
+ORDER BY closes_at
+LIMIT 1
+FOR UPDATE SKIP LOCKED;
+
+-- name: MarkPollNotified :exec
+UPDATE polls SET notified_at = NOW() WHERE chirp_id = $1;
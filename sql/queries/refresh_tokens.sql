@@ -13,9 +13,13 @@ RETURNING *;
 -- name: GetUserFromRefreshToken :one
 SELECT user_id FROM refresh_tokens WHERE token = $1 AND expires_at > NOW() and revoked_at is NULL;
 
--- name: RevokeRefreshToken :exec
-UPDATE refresh_tokens SET revoked_at = NOW(), updated_at = NOW() WHERE token = $1;
+-- name: RevokeRefreshToken :one
+UPDATE refresh_tokens SET revoked_at = NOW(), updated_at = NOW() WHERE token = $1
+RETURNING user_id;
 
 -- name: DeleteAllRefreshTokens :exec
 DELETE FROM refresh_tokens;
 
+
+-- name: GetRefreshTokensByUserId :many
+SELECT * FROM refresh_tokens WHERE user_id = $1 ORDER BY created_at ASC;
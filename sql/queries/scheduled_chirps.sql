@@ -0,0 +1,45 @@
+-- name: CreateScheduledChirp :one
+INSERT INTO scheduled_chirps (id, created_at, updated_at, user_id, body, in_reply_to, conversation_id, quote_of, media_ids, publish_at, visibility, content_warning, sensitive)
+VALUES (
+	gen_random_uuid(),
+	NOW(),
+	NOW(),
+	$1,
+	$2,
+	$3,
+	$4,
+	$5,
+	$6,
+	$7,
+	$8,
+	$9,
+	$10
+	)
+RETURNING *;
+
+-- name: GetScheduledChirps :many
+SELECT * FROM scheduled_chirps
+WHERE user_id = $1
+ORDER BY publish_at ASC, id ASC;
+
+-- name: GetScheduledChirp :one
+SELECT * FROM scheduled_chirps WHERE id = $1 AND user_id = $2;
+
+-- name: UpdateScheduledChirp :one
+UPDATE scheduled_chirps
+SET body = $3, publish_at = $4, status = 'scheduled', failure = NULL, updated_at = NOW()
+WHERE id = $1 AND user_id = $2
+RETURNING *;
+
+-- name: DeleteScheduledChirp :execrows
+DELETE FROM scheduled_chirps WHERE id = $1 AND user_id = $2;
+
+-- name: ClaimDueScheduledChirp :one
+SELECT * FROM scheduled_chirps
+WHERE status = 'scheduled' AND publish_at <= NOW()
+ORDER BY publish_at ASC
+LIMIT 1
+FOR UPDATE SKIP LOCKED;
+
+-- name: FailScheduledChirp :exec
+UPDATE scheduled_chirps SET status = 'failed', failure = $2, updated_at = NOW() WHERE id = $1;
@@ -0,0 +1,27 @@
+-- name: SearchChirps :many
+SELECT sqlc.embed(c), (
+		ln(ts_rank_cd(s.document, websearch_to_tsquery('english', sqlc.arg(text)::text)) + 1e-6)
+		+ extract(epoch FROM c.created_at) / sqlc.arg(recency_seconds)::double precision
+	)::double precision AS score
+FROM chirp_search s
+JOIN chirps c ON c.id = s.chirp_id
+JOIN users u ON u.id = c.user_id
+WHERE (sqlc.arg(text)::text = '' OR s.document @@ websearch_to_tsquery('english', sqlc.arg(text)::text))
+	AND (cardinality(sqlc.arg(from_handles)::text[]) = 0 OR u.handle = ANY(sqlc.arg(from_handles)::text[]))
+	AND (cardinality(sqlc.arg(tags)::text[]) = 0 OR (
+		SELECT count(*) FROM chirp_hashtags ch
+		JOIN hashtags h ON h.id = ch.hashtag_id
+		WHERE ch.chirp_id = c.id AND h.tag = ANY(sqlc.arg(tags)::text[])
+		) = cardinality(sqlc.arg(tags)::text[]))
+	AND (sqlc.narg(since)::timestamp IS NULL OR c.created_at >= sqlc.narg(since)::timestamp)
+	AND (sqlc.narg(until)::timestamp IS NULL OR c.created_at < sqlc.narg(until)::timestamp)
+	AND c.deleted_at IS NULL
+	AND c.rechirp_of IS NULL
+	AND NOT user_hidden_from(c.user_id, sqlc.narg(viewer_id)::uuid)
+	AND can_list_chirp(c, sqlc.narg(viewer_id)::uuid)
+	AND (sqlc.narg(before_score)::double precision IS NULL OR (
+		ln(ts_rank_cd(s.document, websearch_to_tsquery('english', sqlc.arg(text)::text)) + 1e-6)
+		+ extract(epoch FROM c.created_at) / sqlc.arg(recency_seconds)::double precision,
+		c.id) < (sqlc.narg(before_score)::double precision, sqlc.narg(before_id)::uuid))
+ORDER BY score DESC, c.id DESC
+LIMIT sqlc.arg(max_results);
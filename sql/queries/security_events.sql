@@ -0,0 +1,13 @@
+-- name: CreateSecurityEvent :exec
+INSERT INTO security_events (id, created_at, user_id, event, ip_address, user_agent)
+VALUES (
+	gen_random_uuid(),
+	NOW(),
+	$1,
+	$2,
+	$3,
+	$4
+	);
+
+-- name: GetSecurityEventsByUserId :many
+SELECT * FROM security_events WHERE user_id = $1 ORDER BY created_at ASC;
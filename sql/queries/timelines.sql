@@ -0,0 +1,77 @@
+-- name: AddTimelineEntry :exec
+INSERT INTO timeline_entries (user_id, chirp_id, author_id, created_at)
+VALUES (
+	$1,
+	$2,
+	$3,
+	$4
+	)
+ON CONFLICT DO NOTHING;
+
+-- name: FanOutChirp :exec
+INSERT INTO timeline_entries (user_id, chirp_id, author_id, created_at)
+SELECT f.follower_id, sqlc.arg(chirp_id)::uuid, sqlc.arg(author_id)::uuid, sqlc.arg(created_at)::timestamp
+FROM follows f
+WHERE f.followee_id = sqlc.arg(author_id) AND f.status = 'accepted'
+ON CONFLICT DO NOTHING;
+
+-- name: BackfillTimeline :exec
+INSERT INTO timeline_entries (user_id, chirp_id, author_id, created_at)
+SELECT sqlc.arg(user_id)::uuid, c.id, c.user_id, c.created_at
+FROM chirps c
+WHERE c.user_id = sqlc.arg(author_id)
+	AND c.deleted_at IS NULL
+ORDER BY c.created_at DESC
+LIMIT sqlc.arg(max_results)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteTimelineEntriesByAuthor :exec
+DELETE FROM timeline_entries WHERE user_id = $1 AND author_id = $2;
+
+-- name: GetTimeline :many
+SELECT c.* FROM (
+	(
+		SELECT te.chirp_id
+		FROM timeline_entries te
+		JOIN chirps ON chirps.id = te.chirp_id
+		WHERE te.user_id = sqlc.arg(user_id)
+			AND chirps.deleted_at IS NULL
+			AND NOT user_hidden_from(chirps.user_id, sqlc.arg(user_id))
+			AND can_list_chirp(chirps, sqlc.arg(user_id))
+			AND (sqlc.narg(before_created_at)::timestamp IS NULL
+				OR (te.created_at, te.chirp_id) < (sqlc.narg(before_created_at), sqlc.narg(before_id)::uuid))
+		ORDER BY te.created_at DESC, te.chirp_id DESC
+		LIMIT sqlc.arg(max_results)
+	)
+	UNION
+	(
+		SELECT recent.id
+		FROM follows f
+		JOIN users u ON u.id = f.followee_id
+		CROSS JOIN LATERAL (
+			SELECT id FROM chirps
+			WHERE chirps.user_id = f.followee_id
+				AND chirps.deleted_at IS NULL
+				AND NOT user_hidden_from(chirps.user_id, sqlc.arg(user_id))
+				AND can_list_chirp(chirps, sqlc.arg(user_id))
+				AND (sqlc.narg(before_created_at)::timestamp IS NULL
+					OR (chirps.created_at, chirps.id) < (sqlc.narg(before_created_at), sqlc.narg(before_id)::uuid))
+			ORDER BY chirps.created_at DESC, chirps.id DESC
+			LIMIT sqlc.arg(max_results)
+		) recent
+		WHERE f.follower_id = sqlc.arg(user_id)
+			AND f.status = 'accepted'
+			AND u.follower_count > sqlc.arg(fan_out_threshold)::int
+	)
+) page
+JOIN chirps c ON c.id = page.chirp_id
+ORDER BY c.created_at DESC, c.id DESC
+LIMIT sqlc.arg(max_results);
+
+-- name: DeleteTimelineEntriesBetween :exec
+DELETE FROM timeline_entries
+WHERE (user_id = sqlc.arg(user_id) AND author_id = sqlc.arg(other_id))
+	OR (user_id = sqlc.arg(other_id) AND author_id = sqlc.arg(user_id));
+
+-- name: DeleteTimelineEntriesByChirp :exec
+DELETE FROM timeline_entries WHERE chirp_id = $1;
@@ -1,11 +1,14 @@
 -- name: CreateUser :one
-INSERT INTO users (id, created_at, updated_at, email, hashed_password)
+INSERT INTO users (id, created_at, updated_at, email, hashed_password, handle, display_name, status)
 VALUES (
 	gen_random_uuid(), 
 	NOW(), 
 	NOW(), 
 	$1,
-	$2
+	$2,
+	$3,
+	$4,
+	$5
 ) RETURNING * ;
 
 -- name: UpdateUser :one
@@ -20,3 +23,70 @@ UPDATE users SET is_chirpy_red = true WHERE id = $1;
 
 -- name: DeleteAllUsers :exec
 DELETE FROM users;
+
+-- name: GetUser :one
+SELECT * FROM users WHERE id = $1;
+
+-- name: GetUserForViewer :one
+SELECT * FROM users
+WHERE id = sqlc.arg(id)
+	AND status = 'active'
+	AND NOT user_hidden_from(id, sqlc.narg(viewer_id)::uuid);
+
+-- name: GetUserForUpdate :one
+SELECT * FROM users WHERE id = $1 FOR UPDATE;
+
+-- name: UpdateUserSettings :one
+UPDATE users SET requires_follow_approval = $2, sensitive_content = $3, updated_at = NOW() WHERE id = $1
+RETURNING *;
+
+-- name: UpdateUserProfile :one
+UPDATE users SET handle = $2, display_name = $3, updated_at = NOW() WHERE id = $1
+RETURNING *;
+
+-- name: TouchUser :exec
+UPDATE users SET last_active_at = NOW() WHERE id = $1;
+
+-- name: SearchUsers :many
+SELECT * FROM users
+WHERE status = 'active'
+	AND (handle LIKE sqlc.arg(prefix)::text || '%'
+		OR lower(display_name) LIKE sqlc.arg(prefix)::text || '%'
+		OR handle % sqlc.arg(query)::text
+		OR lower(display_name) % sqlc.arg(query)::text)
+	AND NOT EXISTS (
+		SELECT 1 FROM blocks
+		WHERE (blocker_id = users.id AND blocked_id = sqlc.narg(viewer_id)::uuid)
+			OR (blocker_id = sqlc.narg(viewer_id)::uuid AND blocked_id = users.id)
+		)
+	AND (sqlc.narg(before_follower_count)::int IS NULL
+		OR (follower_count, id) < (sqlc.narg(before_follower_count), sqlc.narg(before_id)::uuid))
+ORDER BY follower_count DESC, id DESC
+LIMIT sqlc.arg(max_results);
+
+-- name: GetRecentlyActiveUsers :many
+SELECT * FROM users
+WHERE status = 'active'
+	AND last_active_at IS NOT NULL
+	AND NOT EXISTS (
+		SELECT 1 FROM blocks
+		WHERE (blocker_id = users.id AND blocked_id = sqlc.narg(viewer_id)::uuid)
+			OR (blocker_id = sqlc.narg(viewer_id)::uuid AND blocked_id = users.id)
+		)
+	AND (sqlc.narg(before_active_at)::timestamp IS NULL
+		OR (last_active_at, id) < (sqlc.narg(before_active_at), sqlc.narg(before_id)::uuid))
+ORDER BY last_active_at DESC, id DESC
+LIMIT sqlc.arg(max_results);
+
+-- name: GetPendingUsers :many
+SELECT * FROM users
+WHERE status = 'pending'
+	AND (sqlc.narg(after_created_at)::timestamp IS NULL
+		OR (created_at, id) > (sqlc.narg(after_created_at), sqlc.narg(after_id)::uuid))
+ORDER BY created_at ASC, id ASC
+LIMIT sqlc.arg(max_results);
+
+-- name: SetPendingUserStatus :one
+UPDATE users SET status = $2, updated_at = NOW()
+WHERE id = $1 AND status = 'pending'
+RETURNING *;
@@ -0,0 +1,28 @@
+-- +goose Up
+CREATE TABLE security_events (
+	id UUID PRIMARY KEY,
+	created_at TIMESTAMP NOT NULL,
+	user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	event TEXT NOT NULL,
+	ip_address TEXT NOT NULL,
+	user_agent TEXT NOT NULL
+);
+
+CREATE INDEX security_events_user_id_created_at_idx ON security_events (user_id, created_at);
+
+CREATE TABLE data_exports (
+	id UUID PRIMARY KEY,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'processing', 'complete', 'failed')),
+	file_path TEXT,
+	download_token TEXT UNIQUE,
+	expires_at TIMESTAMP
+);
+
+CREATE INDEX data_exports_status_created_at_idx ON data_exports (status, created_at);
+
+-- +goose Down
+DROP TABLE data_exports;
+DROP TABLE security_events;
@@ -0,0 +1,48 @@
+-- +goose Up
+ALTER TABLE users
+	ADD COLUMN requires_follow_approval BOOLEAN NOT NULL DEFAULT false,
+	ADD COLUMN follower_count INTEGER NOT NULL DEFAULT 0,
+	ADD COLUMN following_count INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE follows (
+	follower_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	followee_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	status TEXT NOT NULL DEFAULT 'accepted' CHECK (status IN ('pending', 'accepted')),
+	PRIMARY KEY (follower_id, followee_id),
+	CHECK (follower_id <> followee_id)
+);
+
+CREATE INDEX follows_followee_id_created_at_idx ON follows (followee_id, created_at, follower_id);
+CREATE INDEX follows_follower_id_created_at_idx ON follows (follower_id, created_at, followee_id);
+
+-- counters only include accepted follows and are kept in step by trigger so
+-- cascading deletes stay correct too
+-- +goose StatementBegin
+CREATE FUNCTION update_follow_counts() RETURNS TRIGGER AS $$
+BEGIN
+	IF TG_OP IN ('UPDATE', 'DELETE') AND OLD.status = 'accepted' THEN
+		UPDATE users SET follower_count = follower_count - 1 WHERE id = OLD.followee_id;
+		UPDATE users SET following_count = following_count - 1 WHERE id = OLD.follower_id;
+	END IF;
+	IF TG_OP IN ('INSERT', 'UPDATE') AND NEW.status = 'accepted' THEN
+		UPDATE users SET follower_count = follower_count + 1 WHERE id = NEW.followee_id;
+		UPDATE users SET following_count = following_count + 1 WHERE id = NEW.follower_id;
+	END IF;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER follows_update_counts
+AFTER INSERT OR UPDATE OF status OR DELETE ON follows
+FOR EACH ROW EXECUTE FUNCTION update_follow_counts();
+
+-- +goose Down
+DROP TABLE follows;
+DROP FUNCTION update_follow_counts;
+ALTER TABLE users
+	DROP COLUMN requires_follow_approval,
+	DROP COLUMN follower_count,
+	DROP COLUMN following_count;
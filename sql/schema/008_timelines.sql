@@ -0,0 +1,21 @@
+-- +goose Up
+ALTER TABLE chirps ADD COLUMN fanned_out_at TIMESTAMP;
+
+CREATE INDEX chirps_fan_out_pending_idx ON chirps (created_at) WHERE fanned_out_at IS NULL;
+CREATE INDEX chirps_user_id_created_at_idx ON chirps (user_id, created_at, id);
+
+CREATE TABLE timeline_entries (
+	user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	chirp_id UUID NOT NULL REFERENCES chirps (id) ON DELETE CASCADE,
+	author_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	created_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (user_id, chirp_id)
+);
+
+CREATE INDEX timeline_entries_user_id_created_at_idx ON timeline_entries (user_id, created_at DESC, chirp_id DESC);
+CREATE INDEX timeline_entries_user_id_author_id_idx ON timeline_entries (user_id, author_id);
+
+-- +goose Down
+DROP TABLE timeline_entries;
+DROP INDEX chirps_user_id_created_at_idx;
+ALTER TABLE chirps DROP COLUMN fanned_out_at;
@@ -0,0 +1,44 @@
+-- +goose Up
+CREATE TABLE blocks (
+	blocker_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	blocked_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	created_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (blocker_id, blocked_id),
+	CHECK (blocker_id <> blocked_id)
+);
+
+CREATE INDEX blocks_blocked_id_idx ON blocks (blocked_id, blocker_id);
+CREATE INDEX blocks_blocker_id_created_at_idx ON blocks (blocker_id, created_at, blocked_id);
+
+CREATE TABLE mutes (
+	muter_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	muted_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	created_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (muter_id, muted_id),
+	CHECK (muter_id <> muted_id)
+);
+
+CREATE INDEX mutes_muter_id_created_at_idx ON mutes (muter_id, created_at, muted_id);
+
+-- every chirp read goes through this so blocks and mutes are applied the
+-- same way everywhere; a NULL viewer is an anonymous request
+-- +goose StatementBegin
+CREATE FUNCTION user_hidden_from(author_id UUID, viewer_id UUID) RETURNS BOOLEAN AS $$
+	SELECT viewer_id IS NOT NULL AND viewer_id <> author_id AND (
+		EXISTS (
+			SELECT 1 FROM blocks
+			WHERE (blocker_id = viewer_id AND blocked_id = author_id)
+				OR (blocker_id = author_id AND blocked_id = viewer_id)
+		)
+		OR EXISTS (
+			SELECT 1 FROM mutes
+			WHERE muter_id = viewer_id AND muted_id = author_id
+		)
+	);
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION user_hidden_from;
+DROP TABLE mutes;
+DROP TABLE blocks;
@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE users
+	ADD COLUMN handle TEXT,
+	ADD COLUMN display_name TEXT,
+	ADD COLUMN last_active_at TIMESTAMP;
+
+CREATE UNIQUE INDEX users_handle_idx ON users (handle);
+CREATE INDEX users_handle_trgm_idx ON users USING GIN (handle gin_trgm_ops);
+CREATE INDEX users_display_name_trgm_idx ON users USING GIN (lower(display_name) gin_trgm_ops);
+CREATE INDEX users_follower_count_idx ON users (follower_count DESC, id DESC);
+CREATE INDEX users_last_active_at_idx ON users (last_active_at DESC, id DESC) WHERE last_active_at IS NOT NULL;
+
+-- +goose Down
+ALTER TABLE users
+	DROP COLUMN handle,
+	DROP COLUMN display_name,
+	DROP COLUMN last_active_at;
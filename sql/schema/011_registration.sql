@@ -0,0 +1,36 @@
+-- +goose Up
+-- admins moderate registrations; the first one has to be granted directly
+-- in the database
+ALTER TABLE users
+	ADD COLUMN status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('pending', 'active', 'rejected')),
+	ADD COLUMN is_admin BOOLEAN NOT NULL DEFAULT false;
+
+CREATE INDEX users_pending_created_at_idx ON users (created_at, id) WHERE status = 'pending';
+
+CREATE TABLE invites (
+	code TEXT PRIMARY KEY,
+	created_at TIMESTAMP NOT NULL,
+	created_by UUID REFERENCES users (id) ON DELETE SET NULL,
+	max_uses INTEGER NOT NULL CHECK (max_uses > 0),
+	uses INTEGER NOT NULL DEFAULT 0,
+	expires_at TIMESTAMP,
+	CHECK (uses <= max_uses)
+);
+
+CREATE INDEX invites_created_by_created_at_idx ON invites (created_by, created_at);
+
+CREATE TABLE invite_redemptions (
+	id UUID PRIMARY KEY,
+	invite_code TEXT NOT NULL REFERENCES invites (code) ON DELETE CASCADE,
+	user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	redeemed_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX invite_redemptions_invite_code_idx ON invite_redemptions (invite_code, redeemed_at);
+
+-- +goose Down
+DROP TABLE invite_redemptions;
+DROP TABLE invites;
+ALTER TABLE users
+	DROP COLUMN status,
+	DROP COLUMN is_admin;
@@ -0,0 +1,39 @@
+-- +goose Up
+ALTER TABLE chirps
+	ADD COLUMN in_reply_to UUID REFERENCES chirps (id) ON DELETE SET NULL,
+	ADD COLUMN conversation_id UUID,
+	ADD COLUMN reply_count INTEGER NOT NULL DEFAULT 0,
+	ADD COLUMN deleted_at TIMESTAMP;
+
+UPDATE chirps SET conversation_id = id;
+ALTER TABLE chirps ALTER COLUMN conversation_id SET NOT NULL;
+
+CREATE INDEX chirps_in_reply_to_idx ON chirps (in_reply_to, created_at, id);
+CREATE INDEX chirps_conversation_id_idx ON chirps (conversation_id);
+
+-- +goose StatementBegin
+CREATE FUNCTION update_reply_count() RETURNS TRIGGER AS $$
+BEGIN
+	IF TG_OP = 'DELETE' AND OLD.in_reply_to IS NOT NULL THEN
+		UPDATE chirps SET reply_count = reply_count - 1 WHERE id = OLD.in_reply_to;
+	END IF;
+	IF TG_OP = 'INSERT' AND NEW.in_reply_to IS NOT NULL THEN
+		UPDATE chirps SET reply_count = reply_count + 1 WHERE id = NEW.in_reply_to;
+	END IF;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER chirps_update_reply_count
+AFTER INSERT OR DELETE ON chirps
+FOR EACH ROW EXECUTE FUNCTION update_reply_count();
+
+-- +goose Down
+DROP TRIGGER chirps_update_reply_count ON chirps;
+DROP FUNCTION update_reply_count;
+ALTER TABLE chirps
+	DROP COLUMN in_reply_to,
+	DROP COLUMN conversation_id,
+	DROP COLUMN reply_count,
+	DROP COLUMN deleted_at;
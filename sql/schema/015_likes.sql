@@ -0,0 +1,34 @@
+-- +goose Up
+ALTER TABLE chirps ADD COLUMN like_count INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE likes (
+	user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	chirp_id UUID NOT NULL REFERENCES chirps (id) ON DELETE CASCADE,
+	created_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (user_id, chirp_id)
+);
+
+CREATE INDEX likes_chirp_id_created_at_idx ON likes (chirp_id, created_at, user_id);
+CREATE INDEX likes_user_id_created_at_idx ON likes (user_id, created_at, chirp_id);
+
+-- +goose StatementBegin
+CREATE FUNCTION update_like_count() RETURNS TRIGGER AS $$
+BEGIN
+	IF TG_OP = 'DELETE' THEN
+		UPDATE chirps SET like_count = like_count - 1 WHERE id = OLD.chirp_id;
+	ELSE
+		UPDATE chirps SET like_count = like_count + 1 WHERE id = NEW.chirp_id;
+	END IF;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER likes_update_like_count
+AFTER INSERT OR DELETE ON likes
+FOR EACH ROW EXECUTE FUNCTION update_like_count();
+
+-- +goose Down
+DROP TABLE likes;
+DROP FUNCTION update_like_count;
+ALTER TABLE chirps DROP COLUMN like_count;
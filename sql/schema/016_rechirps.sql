@@ -0,0 +1,14 @@
+-- +goose Up
+-- no foreign keys here: a rechirp or quote outlives the chirp it points at
+-- and is shown with an unavailable stub instead
+ALTER TABLE chirps
+	ADD COLUMN rechirp_of UUID,
+	ADD COLUMN quote_of UUID;
+
+CREATE UNIQUE INDEX chirps_user_id_rechirp_of_idx ON chirps (user_id, rechirp_of) WHERE rechirp_of IS NOT NULL;
+
+-- +goose Down
+DROP INDEX chirps_user_id_rechirp_of_idx;
+ALTER TABLE chirps
+	DROP COLUMN rechirp_of,
+	DROP COLUMN quote_of;
@@ -0,0 +1,31 @@
+-- +goose Up
+CREATE TABLE hashtags (
+	id UUID PRIMARY KEY,
+	tag TEXT NOT NULL UNIQUE,
+	created_at TIMESTAMP NOT NULL
+);
+
+-- created_at is the chirp's, copied so tag listings and trends can order and
+-- window on this table's index; they only join chirps to leave out deleted
+-- and non-public chirps
+CREATE TABLE chirp_hashtags (
+	chirp_id UUID NOT NULL REFERENCES chirps (id) ON DELETE CASCADE,
+	hashtag_id UUID NOT NULL REFERENCES hashtags (id) ON DELETE CASCADE,
+	created_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (chirp_id, hashtag_id)
+);
+
+CREATE INDEX chirp_hashtags_hashtag_id_created_at_idx ON chirp_hashtags (hashtag_id, created_at, chirp_id);
+CREATE INDEX chirp_hashtags_created_at_idx ON chirp_hashtags (created_at);
+
+CREATE TABLE trends (
+	hashtag_id UUID PRIMARY KEY REFERENCES hashtags (id) ON DELETE CASCADE,
+	score DOUBLE PRECISION NOT NULL,
+	chirp_count INTEGER NOT NULL,
+	computed_at TIMESTAMP NOT NULL
+);
+
+-- +goose Down
+DROP TABLE trends;
+DROP TABLE chirp_hashtags;
+DROP TABLE hashtags;
@@ -0,0 +1,27 @@
+-- +goose Up
+CREATE TABLE chirp_mentions (
+	chirp_id UUID NOT NULL REFERENCES chirps (id) ON DELETE CASCADE,
+	user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	start_offset INTEGER NOT NULL,
+	end_offset INTEGER NOT NULL,
+	PRIMARY KEY (chirp_id, start_offset)
+);
+
+CREATE INDEX chirp_mentions_user_id_idx ON chirp_mentions (user_id);
+
+CREATE TABLE notifications (
+	id UUID PRIMARY KEY,
+	user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	actor_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	type TEXT NOT NULL CHECK (type IN ('mention')),
+	chirp_id UUID REFERENCES chirps (id) ON DELETE CASCADE,
+	created_at TIMESTAMP NOT NULL,
+	read_at TIMESTAMP
+);
+
+CREATE INDEX notifications_user_id_created_at_idx ON notifications (user_id, created_at, id);
+CREATE UNIQUE INDEX notifications_user_id_type_chirp_id_idx ON notifications (user_id, type, chirp_id);
+
+-- +goose Down
+DROP TABLE notifications;
+DROP TABLE chirp_mentions;
@@ -0,0 +1,35 @@
+-- +goose Up
+-- the document lives in its own table rather than as a tsvector column on
+-- chirps: every chirp query selects whole rows, so a column there would be
+-- read and sent back on all of them just to serve search. The trigger keeps
+-- it in step with edits the way a generated column would. Bodies are stored
+-- already cleaned, so only the cleaned text is ever indexed
+CREATE TABLE chirp_search (
+	chirp_id UUID PRIMARY KEY REFERENCES chirps (id) ON DELETE CASCADE,
+	document TSVECTOR NOT NULL
+);
+
+CREATE INDEX chirp_search_document_idx ON chirp_search USING GIN (document);
+
+INSERT INTO chirp_search (chirp_id, document)
+SELECT id, to_tsvector('english', body) FROM chirps;
+
+-- +goose StatementBegin
+CREATE FUNCTION update_chirp_search() RETURNS TRIGGER AS $$
+BEGIN
+	INSERT INTO chirp_search (chirp_id, document)
+	VALUES (NEW.id, to_tsvector('english', NEW.body))
+	ON CONFLICT (chirp_id) DO UPDATE SET document = EXCLUDED.document;
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER chirps_update_search
+AFTER INSERT OR UPDATE OF body ON chirps
+FOR EACH ROW EXECUTE FUNCTION update_chirp_search();
+
+-- +goose Down
+DROP TRIGGER chirps_update_search ON chirps;
+DROP FUNCTION update_chirp_search;
+DROP TABLE chirp_search;
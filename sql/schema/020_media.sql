@@ -0,0 +1,30 @@
+-- +goose Up
+-- uploads start unattached; attached_at stays set once a chirp claims one so
+-- media whose chirp is deleted can be told apart from fresh uploads
+CREATE TABLE media_attachments (
+	id UUID PRIMARY KEY,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	kind TEXT NOT NULL CHECK (kind IN ('image', 'gif', 'video')),
+	content_type TEXT NOT NULL,
+	status TEXT NOT NULL DEFAULT 'processing' CHECK (status IN ('processing', 'ready', 'failed')),
+	claimed_at TIMESTAMP,
+	file_path TEXT NOT NULL,
+	thumbnail_path TEXT,
+	alt_text TEXT NOT NULL DEFAULT '',
+	width INTEGER,
+	height INTEGER,
+	duration_ms INTEGER,
+	blurhash TEXT,
+	chirp_id UUID REFERENCES chirps (id) ON DELETE SET NULL,
+	position INTEGER,
+	attached_at TIMESTAMP
+);
+
+CREATE INDEX media_attachments_chirp_id_position_idx ON media_attachments (chirp_id, position) WHERE chirp_id IS NOT NULL;
+CREATE INDEX media_attachments_processing_idx ON media_attachments (created_at) WHERE status = 'processing';
+CREATE INDEX media_attachments_unattached_idx ON media_attachments (created_at) WHERE chirp_id IS NULL;
+
+-- +goose Down
+DROP TABLE media_attachments;
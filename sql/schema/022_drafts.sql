@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE drafts (
+	id UUID PRIMARY KEY,
+	created_at TIMESTAMP NOT NULL,
+	updated_at TIMESTAMP NOT NULL,
+	user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	body TEXT NOT NULL DEFAULT '',
+	in_reply_to UUID,
+	quote_of UUID,
+	media_ids UUID[] NOT NULL DEFAULT '{}',
+	version INTEGER NOT NULL DEFAULT 1
+);
+
+CREATE INDEX drafts_user_id_updated_at_idx ON drafts (user_id, updated_at DESC, id DESC);
+
+-- +goose Down
+DROP TABLE drafts;
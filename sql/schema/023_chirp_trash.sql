@@ -0,0 +1,13 @@
+-- +goose Up
+ALTER TABLE chirps ADD COLUMN purged_at TIMESTAMP;
+
+-- chirps deleted before now were scrubbed on the spot
+UPDATE chirps SET purged_at = deleted_at WHERE deleted_at IS NOT NULL;
+
+CREATE INDEX chirps_trash_idx ON chirps (user_id, deleted_at DESC, id DESC) WHERE deleted_at IS NOT NULL AND purged_at IS NULL;
+CREATE INDEX chirps_purge_idx ON chirps (deleted_at) WHERE deleted_at IS NOT NULL AND purged_at IS NULL;
+
+-- +goose Down
+DROP INDEX chirps_purge_idx;
+DROP INDEX chirps_trash_idx;
+ALTER TABLE chirps DROP COLUMN purged_at;
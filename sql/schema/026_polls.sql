@@ -0,0 +1,55 @@
+-- +goose Up
+CREATE TABLE polls (
+	chirp_id UUID PRIMARY KEY REFERENCES chirps (id) ON DELETE CASCADE,
+	created_at TIMESTAMP NOT NULL,
+	multiple_choice BOOLEAN NOT NULL DEFAULT FALSE,
+	closes_at TIMESTAMP NOT NULL,
+	voter_count INTEGER NOT NULL DEFAULT 0,
+	notified_at TIMESTAMP
+);
+
+CREATE INDEX polls_closes_at_idx ON polls (closes_at) WHERE notified_at IS NULL;
+
+CREATE TABLE poll_options (
+	chirp_id UUID NOT NULL REFERENCES polls (chirp_id) ON DELETE CASCADE,
+	position INTEGER NOT NULL,
+	body TEXT NOT NULL,
+	vote_count INTEGER NOT NULL DEFAULT 0,
+	PRIMARY KEY (chirp_id, position)
+);
+
+-- one row per voter, so nobody can vote twice however many options they pick
+CREATE TABLE poll_votes (
+	chirp_id UUID NOT NULL REFERENCES polls (chirp_id) ON DELETE CASCADE,
+	user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	choices INTEGER[] NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (chirp_id, user_id)
+);
+
+-- +goose StatementBegin
+CREATE FUNCTION count_poll_vote() RETURNS TRIGGER AS $$
+BEGIN
+	UPDATE polls SET voter_count = voter_count + 1 WHERE chirp_id = NEW.chirp_id;
+	UPDATE poll_options SET vote_count = vote_count + 1
+	WHERE chirp_id = NEW.chirp_id AND position = ANY(NEW.choices);
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER poll_votes_count_poll_vote
+AFTER INSERT ON poll_votes
+FOR EACH ROW EXECUTE FUNCTION count_poll_vote();
+
+ALTER TABLE notifications DROP CONSTRAINT notifications_type_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_type_check CHECK (type IN ('mention', 'poll_closed'));
+
+-- +goose Down
+DELETE FROM notifications WHERE type = 'poll_closed';
+ALTER TABLE notifications DROP CONSTRAINT notifications_type_check;
+ALTER TABLE notifications ADD CONSTRAINT notifications_type_check CHECK (type IN ('mention'));
+DROP TABLE poll_votes;
+DROP FUNCTION count_poll_vote;
+DROP TABLE poll_options;
+DROP TABLE polls;
@@ -0,0 +1,39 @@
+-- +goose Up
+ALTER TABLE chirps ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public'
+	CHECK (visibility IN ('public', 'unlisted', 'followers', 'mentioned'));
+ALTER TABLE scheduled_chirps ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public';
+ALTER TABLE drafts ADD COLUMN visibility TEXT NOT NULL DEFAULT 'public';
+
+-- every read of chirps decides visibility through these two functions, so
+-- the policy lives in one place. can_view_chirp is for chirps reached by
+-- id; can_list_chirp also keeps unlisted chirps out of listings.
+-- +goose StatementBegin
+CREATE FUNCTION can_view_chirp(chirp chirps, viewer_id UUID) RETURNS BOOLEAN AS $$
+	SELECT chirp.visibility IN ('public', 'unlisted')
+		OR chirp.user_id IS NOT DISTINCT FROM viewer_id
+		OR (chirp.visibility = 'followers' AND EXISTS (
+			SELECT 1 FROM follows
+			WHERE follower_id = viewer_id
+				AND followee_id = chirp.user_id
+				AND status = 'accepted'
+		))
+		OR EXISTS (
+			SELECT 1 FROM chirp_mentions
+			WHERE chirp_id = chirp.id AND user_id = viewer_id
+		);
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE FUNCTION can_list_chirp(chirp chirps, viewer_id UUID) RETURNS BOOLEAN AS $$
+	SELECT (chirp.visibility <> 'unlisted' OR chirp.user_id IS NOT DISTINCT FROM viewer_id)
+		AND can_view_chirp(chirp, viewer_id);
+$$ LANGUAGE sql STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION can_list_chirp;
+DROP FUNCTION can_view_chirp;
+ALTER TABLE drafts DROP COLUMN visibility;
+ALTER TABLE scheduled_chirps DROP COLUMN visibility;
+ALTER TABLE chirps DROP COLUMN visibility;
@@ -0,0 +1,24 @@
+-- +goose Up
+-- sensitive is the author's own flag; sensitive_forced is set by moderators
+-- and is kept apart so clearing it never undoes what the author chose
+ALTER TABLE chirps
+	ADD COLUMN content_warning TEXT,
+	ADD COLUMN sensitive BOOLEAN NOT NULL DEFAULT FALSE,
+	ADD COLUMN sensitive_forced BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE scheduled_chirps
+	ADD COLUMN content_warning TEXT,
+	ADD COLUMN sensitive BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE drafts
+	ADD COLUMN content_warning TEXT,
+	ADD COLUMN sensitive BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE users ADD COLUMN sensitive_content TEXT NOT NULL DEFAULT 'blur'
+	CHECK (sensitive_content IN ('hide', 'blur', 'show'));
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN sensitive_content;
+ALTER TABLE drafts DROP COLUMN content_warning, DROP COLUMN sensitive;
+ALTER TABLE scheduled_chirps DROP COLUMN content_warning, DROP COLUMN sensitive;
+ALTER TABLE chirps DROP COLUMN content_warning, DROP COLUMN sensitive, DROP COLUMN sensitive_forced;
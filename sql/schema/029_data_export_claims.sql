@@ -0,0 +1,6 @@
+-- +goose Up
+-- claimed_at lets another worker pick up an export whose worker died
+ALTER TABLE data_exports ADD COLUMN claimed_at TIMESTAMP;
+
+-- +goose Down
+ALTER TABLE data_exports DROP COLUMN claimed_at;
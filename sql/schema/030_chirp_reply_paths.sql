@@ -0,0 +1,26 @@
+-- +goose Up
+-- reply_path holds a reply's ancestors from the conversation root down, so
+-- a chirp's descendants can be paged off the conversation index without
+-- walking the reply tree again for every page
+ALTER TABLE chirps ADD COLUMN reply_path UUID[] NOT NULL DEFAULT '{}';
+
+WITH RECURSIVE paths AS (
+	SELECT id, ARRAY[]::uuid[] AS reply_path
+	FROM chirps
+	WHERE in_reply_to IS NULL
+	UNION ALL
+	SELECT child.id, p.reply_path || child.in_reply_to
+	FROM paths p
+	JOIN chirps child ON child.in_reply_to = p.id
+)
+UPDATE chirps SET reply_path = paths.reply_path
+FROM paths
+WHERE chirps.id = paths.id AND cardinality(paths.reply_path) > 0;
+
+DROP INDEX chirps_conversation_id_idx;
+CREATE INDEX chirps_conversation_id_created_at_idx ON chirps (conversation_id, created_at, id);
+
+-- +goose Down
+DROP INDEX chirps_conversation_id_created_at_idx;
+CREATE INDEX chirps_conversation_id_idx ON chirps (conversation_id);
+ALTER TABLE chirps DROP COLUMN reply_path;
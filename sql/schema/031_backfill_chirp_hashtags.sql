@@ -0,0 +1,25 @@
+-- +goose Up
+-- hashtags are only parsed out of chirps as they are written, so chirps from
+-- before 017 need theirs picked up here, using the same rules as
+-- entities.Hashtags
+CREATE TEMP TABLE backfill_hashtags AS
+SELECT DISTINCT c.id AS chirp_id, c.created_at, lower(m[1]) AS tag
+FROM chirps c
+CROSS JOIN LATERAL regexp_matches(c.body, '(?:^|[^[:alnum:]_&/#])#([[:alnum:]_]*[[:alpha:]_][[:alnum:]_]*)', 'g') AS m
+WHERE octet_length(m[1]) <= 100;
+
+INSERT INTO hashtags (id, tag, created_at)
+SELECT gen_random_uuid(), tag, NOW()
+FROM (SELECT DISTINCT tag FROM backfill_hashtags) tags
+ON CONFLICT (tag) DO NOTHING;
+
+INSERT INTO chirp_hashtags (chirp_id, hashtag_id, created_at)
+SELECT b.chirp_id, h.id, b.created_at
+FROM backfill_hashtags b
+JOIN hashtags h ON h.tag = b.tag
+ON CONFLICT (chirp_id, hashtag_id) DO NOTHING;
+
+DROP TABLE backfill_hashtags;
+
+-- +goose Down
+-- backfilled tags can't be told apart from ones added since, so they stay
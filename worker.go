@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// runPeriodically runs job every interval for the life of the process. The
+// wake channel lets request handlers ask for an early run.
+func runPeriodically(name string, interval time.Duration, wake <-chan struct{}, job func(context.Context) error) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := job(context.Background()); err != nil {
+				log.Printf("%v failed :: %v", name, err)
+			}
+
+			select {
+			case <-ticker.C:
+			case <-wake:
+			}
+		}
+	}()
+}
+
+func notify(wake chan struct{}) {
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}